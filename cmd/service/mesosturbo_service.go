@@ -130,7 +130,7 @@ func (s *MesosTurboService) Run(_ []string) error {
 
 	// ============================================
 	// Mesos Probe Registration Client
-	registrationClient := mesos.NewRegistrationClient(mesosMasterType)
+	registrationClient := mesos.NewRegistrationClient(mesosMasterType, mesosTargetConf.Stitching)
 
 	// Mesos Probe Discovery Client
 	discoveryClient, err := discovery.NewDiscoveryClient(mesosMasterType, mesosTargetConf)
@@ -146,6 +146,7 @@ func (s *MesosTurboService) Run(_ []string) error {
 			WithTurboCommunicator(turboCommConfigData).
 			WithTurboProbe(probe.NewProbeBuilder(string(mesosMasterType), probeCategory).
 				RegisteredBy(registrationClient).
+				WithActionPolicies(registrationClient).
 				DiscoversTarget(mesosTarget, discoveryClient)).
 			Create()
 
@@ -0,0 +1,58 @@
+package conf
+
+import (
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"strings"
+)
+
+// Actions on the containers and applications of the tasks that can be executed by each framework.
+// The frameworks are matched by the name prefix so that multiple instances like marathon-user are included.
+// The tasks of the frameworks that are not listed are analyzed without actions.
+var frameworkActions = map[string]map[proto.EntityDTO_EntityType][]proto.ActionItemDTO_ActionType{
+	"marathon": {
+		proto.EntityDTO_CONTAINER: {
+			proto.ActionItemDTO_MOVE,
+			proto.ActionItemDTO_PROVISION,
+			proto.ActionItemDTO_SUSPEND,
+			proto.ActionItemDTO_RIGHT_SIZE,
+		},
+		proto.EntityDTO_APPLICATION: {
+			proto.ActionItemDTO_PROVISION,
+			proto.ActionItemDTO_SUSPEND,
+		},
+	},
+	"metronome": {
+		proto.EntityDTO_CONTAINER: {
+			proto.ActionItemDTO_MOVE,
+			proto.ActionItemDTO_RIGHT_SIZE,
+		},
+	},
+}
+
+// Actions supported by the framework for the entity type
+func GetFrameworkActions(frameworkName string, entityType proto.EntityDTO_EntityType) []proto.ActionItemDTO_ActionType {
+	name := strings.ToLower(frameworkName)
+	for framework, entityActions := range frameworkActions {
+		if name == framework || strings.HasPrefix(name, framework+"-") {
+			return append([]proto.ActionItemDTO_ActionType{}, entityActions[entityType]...)
+		}
+	}
+	return nil
+}
+
+// Actions supported by any of the frameworks for the entity type.
+// The action policy registered with the server applies to all the entities of a type, so the actions
+// that are not supported by the framework of a task are disabled by marking the entity as not controllable.
+func GetEntityActions(entityType proto.EntityDTO_EntityType) []proto.ActionItemDTO_ActionType {
+	var actionTypes []proto.ActionItemDTO_ActionType
+	seen := make(map[proto.ActionItemDTO_ActionType]bool)
+	for _, entityActions := range frameworkActions {
+		for _, actionType := range entityActions[entityType] {
+			if !seen[actionType] {
+				seen[actionType] = true
+				actionTypes = append(actionTypes, actionType)
+			}
+		}
+	}
+	return actionTypes
+}
@@ -0,0 +1,29 @@
+package conf
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"testing"
+)
+
+func TestGetFrameworkActions(t *testing.T) {
+	assert.Equal(t, 4, len(GetFrameworkActions("marathon", proto.EntityDTO_CONTAINER)))
+	assert.Equal(t, 2, len(GetFrameworkActions("Marathon-User", proto.EntityDTO_APPLICATION)))
+	assert.Equal(t, 0, len(GetFrameworkActions("metronome", proto.EntityDTO_APPLICATION)))
+	assert.Equal(t, 0, len(GetFrameworkActions("marathonx", proto.EntityDTO_CONTAINER)))
+	assert.Equal(t, 0, len(GetFrameworkActions("spark", proto.EntityDTO_CONTAINER)))
+
+	// the returned actions do not modify the table
+	actions := GetFrameworkActions("metronome", proto.EntityDTO_CONTAINER)
+	actions[0] = proto.ActionItemDTO_SUSPEND
+	assert.Equal(t, proto.ActionItemDTO_MOVE, GetFrameworkActions("metronome", proto.EntityDTO_CONTAINER)[0])
+}
+
+func TestGetEntityActions(t *testing.T) {
+	// union of the actions of all the frameworks
+	assert.ElementsMatch(t, []proto.ActionItemDTO_ActionType{proto.ActionItemDTO_MOVE, proto.ActionItemDTO_PROVISION,
+		proto.ActionItemDTO_SUSPEND, proto.ActionItemDTO_RIGHT_SIZE}, GetEntityActions(proto.EntityDTO_CONTAINER))
+	assert.ElementsMatch(t, []proto.ActionItemDTO_ActionType{proto.ActionItemDTO_PROVISION, proto.ActionItemDTO_SUSPEND},
+		GetEntityActions(proto.EntityDTO_APPLICATION))
+	assert.Equal(t, 0, len(GetEntityActions(proto.EntityDTO_VIRTUAL_MACHINE)))
+}
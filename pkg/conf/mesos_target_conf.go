@@ -6,11 +6,29 @@ import (
 	"github.com/golang/glog"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	"io/ioutil"
+	"regexp"
 )
 
 const (
 	DEFAULT_APACHE_MESOS_MASTER_PORT string = "5050"
 	DEFAULT_DCOS_MESOS_MASTER_PORT   string = ""
+
+	DEFAULT_SAMPLING_INTERVAL_SECS int     = 60
+	DEFAULT_SAMPLING_BUFFER_SIZE   int     = 20
+	DEFAULT_SAMPLING_PERCENTILE    float64 = 95
+
+	DEFAULT_PROMETHEUS_TIMEOUT_SECS int = 30
+
+	DEFAULT_CPU_MHZ_ATTRIBUTE string = "cpu_mhz"
+
+	DEFAULT_STATS_CACHE_MAX_AGE_SECS int = 900
+
+	DEFAULT_DISCOVERY_TIMEOUT_SECS int = 240
+	DEFAULT_AGENT_TIMEOUT_SECS     int = 60
+
+	DEFAULT_MAX_CONCURRENT_AGENTS int = 50
+	DEFAULT_WORKER_CONCURRENCY    int = 10
+	DEFAULT_WORKER_COUNT          int = 10
 )
 
 // Configuration Parameters for the Mesos Target that is registered with the Operations Manager
@@ -24,6 +42,49 @@ type MesosTargetConf struct {
 	MasterPassword string `json:"master-pwd,omitempty"`
 
 	FrameworkConf `json:"framework,omitempty"`
+
+	// Background sampling of the agent statistics between discoveries
+	Sampling *SamplingConf `json:"sampling,omitempty"`
+
+	// Names of the monitors used to collect the metrics, defaults to the Mesos agent monitor
+	Monitors []string `json:"monitors,omitempty"`
+	// Run the monitors in the configured order or in parallel for each agent, defaults to sequential
+	MonitorExecution MonitorExecutionMode `json:"monitor-execution,omitempty"`
+	// Metrics from a monitor are only used if they were not set by the previous monitors
+	FillMissingMetrics bool `json:"fill-missing-metrics,omitempty"`
+	// Prometheus server used by the Prometheus monitor
+	Prometheus *PrometheusConf `json:"prometheus,omitempty"`
+
+	// CPU speed of the agents used to convert the CPU units to MHz
+	CPUFrequency *CPUFrequencyConf `json:"cpu-frequency,omitempty"`
+
+	// On-disk snapshot of the raw agent statistics used to compute the usage after a probe restart
+	StatsCache *StatsCacheConf `json:"stats-cache,omitempty"`
+
+	// Deadline for the discovery of all the agents, the response contains the agents discovered before the deadline
+	DiscoveryTimeoutSecs int `json:"discovery-timeout-secs,omitempty"`
+	// Deadline for the discovery of each agent
+	AgentTimeoutSecs int `json:"agent-timeout-secs,omitempty"`
+
+	// Maximum number of agents discovered concurrently by all the discovery workers
+	MaxConcurrentAgents int `json:"max-concurrent-agents,omitempty"`
+	// Maximum number of agents discovered concurrently by each discovery worker
+	WorkerConcurrency int `json:"worker-concurrency,omitempty"`
+	// Strategy used to group the agents into discovery workers -
+	// Fixed_Agent_Size, Fixed_Worker_Size, One_Worker_Per_Agent or Adaptive_Latency, defaults to Fixed_Worker_Size
+	WorkerStrategy WorkerSelectionStrategy `json:"worker-strategy,omitempty"`
+	// Number of agents per worker for Fixed_Agent_Size, else the number of workers
+	WorkerCount int `json:"worker-count,omitempty"`
+
+	// Number of discoveries the last known metrics are used for an agent that does not respond,
+	// after which the agent is reported as unavailable. Disabled if not specified.
+	LastKnownMetricsCycles int `json:"last-known-metrics-cycles,omitempty"`
+
+	// Stitching of the discovered entities with the entities discovered by the other targets
+	Stitching *StitchingConf `json:"stitching,omitempty"`
+
+	// Frameworks, agents and tasks included or excluded from the discovery
+	Filters *FilterConf `json:"filters,omitempty"`
 }
 
 // Configuration of a Master node
@@ -48,6 +109,105 @@ type FrameworkConf struct {
 	FrameworkPassword string             `json:"framework-pwd"`
 }
 
+// Configuration for the sampler that polls the agent statistics at a sub-interval between two discoveries
+type SamplingConf struct {
+	Enabled bool `json:"enabled"`
+	// Polling interval in seconds
+	IntervalSecs int `json:"interval-secs,omitempty"`
+	// Maximum number of samples kept for each task
+	BufferSize int `json:"buffer-size,omitempty"`
+	// Percentile computed from the samples, between 0 and 100
+	Percentile float64 `json:"percentile,omitempty"`
+	// Value reported as the commodity used value, 'average' or 'percentile'
+	UsedValue SampledValueType `json:"used-value,omitempty"`
+}
+
+// Configuration for the Prometheus server and the queries used to collect the metrics
+type PrometheusConf struct {
+	// Prometheus server url, e.g. http://prometheus:9090
+	Url string `json:"url"`
+	// Timeout in seconds for each query
+	TimeoutSecs int `json:"timeout-secs,omitempty"`
+	// Queries for the metrics of each entity and resource type
+	Queries []PrometheusQueryConf `json:"queries"`
+}
+
+// PromQL query for a metric of an entity type.
+// The query may contain the $agent_ip, $agent_hostname and $agent_id placeholders which are replaced for each agent.
+type PrometheusQueryConf struct {
+	// Entity type - Node, Container or App
+	EntityType string `json:"entity-type"`
+	// Resource type - CPU, MEM, CPU_PROV, MEM_PROV
+	ResourceType string `json:"resource-type"`
+	// Metric type - Used, Capacity or Peak
+	MetricType string `json:"metric-type"`
+	Query      string `json:"query"`
+	// Label in the query result containing the task id, required for Container and App entities
+	TaskLabel string `json:"task-label,omitempty"`
+	// Multiplier to convert the query result to the units used by the probe, MHz for CPU and KB for memory
+	Multiplier float64 `json:"multiplier,omitempty"`
+}
+
+// Configuration for the CPU speed of the agents.
+// The speed for an agent is selected from the host override, the agent attribute, the Prometheus query
+// and then the default value, in that order.
+type CPUFrequencyConf struct {
+	// CPU speed in MHz for the agents that have no other source, defaults to the probe CPU multiplier
+	DefaultMHz float64 `json:"default-mhz,omitempty"`
+	// CPU speed in MHz for specific agents using the agent hostname or IP
+	HostOverrides map[string]float64 `json:"host-overrides,omitempty"`
+	// Agent attribute with the CPU speed in MHz, defaults to cpu_mhz
+	Attribute string `json:"attribute,omitempty"`
+	// PromQL query returning the CPU speed in MHz for an agent, using the Prometheus server config.
+	// The query may contain the $agent_ip, $agent_hostname and $agent_id placeholders.
+	PrometheusQuery string `json:"prometheus-query,omitempty"`
+}
+
+// Configuration for the snapshot of the raw statistics saved after each discovery
+type StatsCacheConf struct {
+	// Path of the snapshot file
+	File string `json:"file"`
+	// Snapshots older than this are ignored when loaded
+	MaxAgeSecs int `json:"max-age-secs,omitempty"`
+}
+
+// Configuration for stitching the discovered entities with the entities discovered by the other targets
+type StitchingConf struct {
+	// Stitch the applications with the applications discovered by the APM and load balancer targets
+	// using the IP address of the container and the port from the task discovery info
+	Applications bool `json:"applications,omitempty"`
+	// Agent property used to stitch the agent VMs - ip, instance-id or uuid, defaults to the agent IP
+	VMKey VMStitchingKey `json:"vm-key,omitempty"`
+	// Agent attribute with the cloud instance id or the VM UUID, required for the instance-id and uuid keys
+	VMAttribute string `json:"vm-attribute,omitempty"`
+	// Discover the agents as VMs hosted by physical machines in a datacenter, without stitching the VMs,
+	// for the clusters that are not discovered by an infrastructure target
+	Standalone bool `json:"standalone,omitempty"`
+}
+
+// Include and exclude filters applied to the agents and tasks in the master state.
+// An agent or task is filtered if it does not match the include rules or if it matches the exclude rules.
+type FilterConf struct {
+	Include *FilterRules `json:"include,omitempty"`
+	Exclude *FilterRules `json:"exclude,omitempty"`
+	// Discover the filtered agents and tasks as entities that are not controllable instead of omitting them
+	NonControllable bool `json:"non-controllable,omitempty"`
+}
+
+// Rules matching the agents and tasks, an entity matches if it matches any of the rules
+type FilterRules struct {
+	// Names or ids of the frameworks of the tasks
+	Frameworks []string `json:"frameworks,omitempty"`
+	// Roles of the tasks
+	Roles []string `json:"roles,omitempty"`
+	// Regular expressions for the agent hostnames
+	AgentHostnames []string `json:"agent-hostnames,omitempty"`
+	// Agent attribute values by attribute name
+	AgentAttributes map[string]string `json:"agent-attributes,omitempty"`
+	// Task label values by label key
+	TaskLabels map[string]string `json:"task-labels,omitempty"`
+}
+
 type ActionFrameworkConf struct {
 	// Action Executor related to using Layer-X
 	ActionIP   string
@@ -71,6 +231,8 @@ func NewMesosTargetConf(targetConfigFilePath string) (*MesosTargetConf, error) {
 
 	// Provide framework ip for dcos mesos
 	dcosMesosTargetConf(config)
+	// Defaults for the optional parameters
+	config.setDefaults()
 
 	// Validate
 	ok, err := config.validate()
@@ -131,6 +293,8 @@ func CreateMesosTargetConf(targetType string, accountValues []*proto.AccountValu
 
 	// Provide framework ip for dcos mesos
 	dcosMesosTargetConf(config)
+	// Defaults for the optional parameters
+	config.setDefaults()
 	// Validate
 	ok, err := config.validate()
 	if !ok {
@@ -216,9 +380,146 @@ func (conf *MesosTargetConf) validate() (bool, error) {
 	if conf.MasterIPPort == "" {
 		return false, fmt.Errorf("Mesos Master IP:Port list is required :  %+v" + fmt.Sprint(conf))
 	}
+
+	if sampling := conf.Sampling; sampling != nil && sampling.Enabled {
+		if sampling.Percentile <= 0 || sampling.Percentile > 100 {
+			return false, fmt.Errorf("Sampling percentile must be between 0 and 100 : %f", sampling.Percentile)
+		}
+		if sampling.UsedValue != SampledAverage && sampling.UsedValue != SampledPercentile {
+			return false, fmt.Errorf("Invalid sampling used value : %s", sampling.UsedValue)
+		}
+	}
+
+	if mode := conf.MonitorExecution; mode != "" && mode != MonitorSequential && mode != MonitorParallel {
+		return false, fmt.Errorf("Invalid monitor execution mode : %s", conf.MonitorExecution)
+	}
+
+	switch conf.WorkerStrategy {
+	case "", FixedAgentSize, FixedWorkerSize, OneWorkerPerAgent, AdaptiveLatency:
+	default:
+		return false, fmt.Errorf("Invalid worker strategy : %s", conf.WorkerStrategy)
+	}
+
+	if cpuFrequency := conf.CPUFrequency; cpuFrequency != nil {
+		if cpuFrequency.DefaultMHz < 0 {
+			return false, fmt.Errorf("Invalid default CPU speed : %f", cpuFrequency.DefaultMHz)
+		}
+		for host, mhz := range cpuFrequency.HostOverrides {
+			if mhz <= 0 {
+				return false, fmt.Errorf("Invalid CPU speed %f for host %s", mhz, host)
+			}
+		}
+		if cpuFrequency.PrometheusQuery != "" && conf.Prometheus == nil {
+			return false, fmt.Errorf("Prometheus server config is required for the CPU speed query")
+		}
+	}
+
+	if conf.LastKnownMetricsCycles < 0 {
+		return false, fmt.Errorf("Invalid last known metrics cycles : %d", conf.LastKnownMetricsCycles)
+	}
+
+	if statsCache := conf.StatsCache; statsCache != nil && statsCache.File == "" {
+		return false, fmt.Errorf("Stats cache file is required")
+	}
+
+	if stitching := conf.Stitching; stitching != nil {
+		switch stitching.VMKey {
+		case "", StitchByIP:
+		case StitchByInstanceId, StitchByUUID:
+			if stitching.VMAttribute == "" {
+				return false, fmt.Errorf("Agent attribute is required for the VM stitching key %s", stitching.VMKey)
+			}
+		default:
+			return false, fmt.Errorf("Invalid VM stitching key : %s", stitching.VMKey)
+		}
+	}
+
+	if filters := conf.Filters; filters != nil {
+		for _, rules := range []*FilterRules{filters.Include, filters.Exclude} {
+			if rules == nil {
+				continue
+			}
+			for _, hostname := range rules.AgentHostnames {
+				if _, err := regexp.Compile(hostname); err != nil {
+					return false, fmt.Errorf("Invalid agent hostname filter %s : %s", hostname, err)
+				}
+			}
+		}
+	}
+
+	if prometheus := conf.Prometheus; prometheus != nil {
+		if prometheus.Url == "" {
+			return false, fmt.Errorf("Prometheus server url is required")
+		}
+		for _, query := range prometheus.Queries {
+			if query.Query == "" || query.EntityType == "" || query.ResourceType == "" || query.MetricType == "" {
+				return false, fmt.Errorf("Incomplete prometheus query config : %+v", query)
+			}
+		}
+	}
 	return true, nil
 }
 
+// Set the default values for the optional parameters that are not specified
+func (conf *MesosTargetConf) setDefaults() {
+	if conf.DiscoveryTimeoutSecs <= 0 {
+		conf.DiscoveryTimeoutSecs = DEFAULT_DISCOVERY_TIMEOUT_SECS
+	}
+	if conf.AgentTimeoutSecs <= 0 {
+		conf.AgentTimeoutSecs = DEFAULT_AGENT_TIMEOUT_SECS
+	}
+	if conf.MaxConcurrentAgents <= 0 {
+		conf.MaxConcurrentAgents = DEFAULT_MAX_CONCURRENT_AGENTS
+	}
+	if conf.WorkerConcurrency <= 0 {
+		conf.WorkerConcurrency = DEFAULT_WORKER_CONCURRENCY
+	}
+	if conf.WorkerStrategy == "" {
+		conf.WorkerStrategy = FixedWorkerSize
+	}
+	if conf.WorkerCount <= 0 {
+		conf.WorkerCount = DEFAULT_WORKER_COUNT
+	}
+	if conf.MonitorExecution == "" {
+		conf.MonitorExecution = MonitorSequential
+	}
+	if sampling := conf.Sampling; sampling != nil {
+		if sampling.IntervalSecs <= 0 {
+			sampling.IntervalSecs = DEFAULT_SAMPLING_INTERVAL_SECS
+		}
+		if sampling.BufferSize <= 0 {
+			sampling.BufferSize = DEFAULT_SAMPLING_BUFFER_SIZE
+		}
+		if sampling.Percentile == 0 {
+			sampling.Percentile = DEFAULT_SAMPLING_PERCENTILE
+		}
+		if sampling.UsedValue == "" {
+			sampling.UsedValue = SampledAverage
+		}
+	}
+	if cpuFrequency := conf.CPUFrequency; cpuFrequency != nil {
+		if cpuFrequency.Attribute == "" {
+			cpuFrequency.Attribute = DEFAULT_CPU_MHZ_ATTRIBUTE
+		}
+	}
+	if stitching := conf.Stitching; stitching != nil && stitching.VMKey == "" {
+		stitching.VMKey = StitchByIP
+	}
+	if statsCache := conf.StatsCache; statsCache != nil && statsCache.MaxAgeSecs <= 0 {
+		statsCache.MaxAgeSecs = DEFAULT_STATS_CACHE_MAX_AGE_SECS
+	}
+	if prometheus := conf.Prometheus; prometheus != nil {
+		if prometheus.TimeoutSecs <= 0 {
+			prometheus.TimeoutSecs = DEFAULT_PROMETHEUS_TIMEOUT_SECS
+		}
+		for idx := range prometheus.Queries {
+			if prometheus.Queries[idx].Multiplier == 0 {
+				prometheus.Queries[idx].Multiplier = 1
+			}
+		}
+	}
+}
+
 // Get the config from file.
 func readConfig(path string) (*MesosTargetConf, error) {
 	file, e := ioutil.ReadFile(path)
@@ -69,8 +69,7 @@ func TestCreateMesosTargetConf(t *testing.T) {
 	acctValues := createApacheAccValues()
 	targetConf, err := CreateMesosTargetConf(string(Apache), acctValues)
 	assert.Nil(t, err)
-	_, ok := targetConf.(*MesosTargetConf)
-	assert.True(t, true, ok)
+	assert.NotNil(t, targetConf)
 }
 
 func createApacheAccValues() []*proto.AccountValue {
@@ -100,3 +99,92 @@ func createApacheAccValues() []*proto.AccountValue {
 	accountValues = append(accountValues, accVal)
 	return accountValues
 }
+
+func TestWorkerStrategyConfig(t *testing.T) {
+	testCases := []struct {
+		strategy WorkerSelectionStrategy
+		valid    bool
+	}{
+		{"", true},
+		{FixedAgentSize, true},
+		{FixedWorkerSize, true},
+		{OneWorkerPerAgent, true},
+		{AdaptiveLatency, true},
+		{"Round_Robin", false},
+	}
+	for _, testCase := range testCases {
+		conf := &MesosTargetConf{
+			Master:         Apache,
+			MasterIPPort:   "127.0.0.1:5050",
+			WorkerStrategy: testCase.strategy,
+		}
+		valid, err := conf.validate()
+		assert.Equal(t, testCase.valid, valid, fmt.Sprintf("Worker strategy %s : %s", testCase.strategy, err))
+	}
+
+	conf := &MesosTargetConf{}
+	conf.setDefaults()
+	assert.Equal(t, FixedWorkerSize, conf.WorkerStrategy)
+	assert.Equal(t, DEFAULT_WORKER_COUNT, conf.WorkerCount)
+}
+
+func TestLastKnownMetricsConfig(t *testing.T) {
+	conf := &MesosTargetConf{Master: Apache, MasterIPPort: "127.0.0.1:5050"}
+	conf.setDefaults()
+	assert.Equal(t, 0, conf.LastKnownMetricsCycles, "Last known metrics should be disabled by default")
+
+	conf.LastKnownMetricsCycles = -1
+	valid, _ := conf.validate()
+	assert.False(t, valid)
+}
+
+func TestStitchingConfig(t *testing.T) {
+	testCases := []struct {
+		stitching *StitchingConf
+		valid     bool
+	}{
+		{&StitchingConf{}, true},
+		{&StitchingConf{VMKey: StitchByIP}, true},
+		{&StitchingConf{VMKey: "hostname"}, false},
+		{&StitchingConf{VMKey: StitchByInstanceId, VMAttribute: "instance_id"}, true},
+		{&StitchingConf{VMKey: StitchByUUID, VMAttribute: "vm_uuid"}, true},
+		{&StitchingConf{VMKey: StitchByInstanceId}, false},
+		{&StitchingConf{VMKey: StitchByUUID}, false},
+		{&StitchingConf{VMKey: "mac"}, false},
+	}
+	for _, testCase := range testCases {
+		conf := &MesosTargetConf{
+			Master:       Apache,
+			MasterIPPort: "127.0.0.1:5050",
+			Stitching:    testCase.stitching,
+		}
+		valid, err := conf.validate()
+		assert.Equal(t, testCase.valid, valid, fmt.Sprintf("Stitching config %+v : %s", testCase.stitching, err))
+	}
+
+	conf := &MesosTargetConf{Stitching: &StitchingConf{}}
+	conf.setDefaults()
+	assert.Equal(t, StitchByIP, conf.Stitching.VMKey)
+}
+
+func TestFiltersConfig(t *testing.T) {
+	testCases := []struct {
+		filters *FilterConf
+		valid   bool
+	}{
+		{&FilterConf{}, true},
+		{&FilterConf{Include: &FilterRules{AgentHostnames: []string{"^agent-[0-9]+$"}}}, true},
+		{&FilterConf{Exclude: &FilterRules{AgentHostnames: []string{"gpu.*"}, Frameworks: []string{"spark"}}}, true},
+		{&FilterConf{Include: &FilterRules{AgentHostnames: []string{"agent-(["}}}, false},
+		{&FilterConf{Exclude: &FilterRules{AgentHostnames: []string{"ok", "*bad"}}}, false},
+	}
+	for _, testCase := range testCases {
+		conf := &MesosTargetConf{
+			Master:       Apache,
+			MasterIPPort: "127.0.0.1:5050",
+			Filters:      testCase.filters,
+		}
+		valid, err := conf.validate()
+		assert.Equal(t, testCase.valid, valid, fmt.Sprintf("Filters config %+v : %s", testCase.filters, err))
+	}
+}
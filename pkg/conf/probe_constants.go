@@ -18,6 +18,41 @@ const (
 	Hadoop        MesosFrameworkType = "Hadoop"
 )
 
+// Represents the value computed from the sampled statistics that is reported as the used value
+type SampledValueType string
+
+const (
+	SampledAverage    SampledValueType = "average"
+	SampledPercentile SampledValueType = "percentile"
+)
+
+// Represents how the monitors configured for a target are executed for each agent
+type MonitorExecutionMode string
+
+const (
+	MonitorSequential MonitorExecutionMode = "sequential"
+	MonitorParallel   MonitorExecutionMode = "parallel"
+)
+
+// Represents how the agents are grouped into the discovery workers
+type WorkerSelectionStrategy string
+
+const (
+	FixedAgentSize    WorkerSelectionStrategy = "Fixed_Agent_Size"
+	FixedWorkerSize   WorkerSelectionStrategy = "Fixed_Worker_Size"
+	OneWorkerPerAgent WorkerSelectionStrategy = "One_Worker_Per_Agent"
+	AdaptiveLatency   WorkerSelectionStrategy = "Adaptive_Latency"
+)
+
+// Represents the agent property used to stitch the agent VMs with the VMs discovered by the hypervisor and cloud targets
+type VMStitchingKey string
+
+const (
+	StitchByIP         VMStitchingKey = "ip"
+	StitchByInstanceId VMStitchingKey = "instance-id"
+	StitchByUUID       VMStitchingKey = "uuid"
+)
+
 // ==========================================================================
 type ProbeCategory string
 
@@ -31,4 +31,5 @@ const (
 	NODE      EntityType = "Node"
 	CONTAINER EntityType = "Container"
 	APP       EntityType = "App"
+	POD       EntityType = "Pod"
 )
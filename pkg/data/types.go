@@ -17,6 +17,17 @@ type MesosAPIResponse struct {
 	//DeActivatedSlaves float64     `json:"deactivated_slaves"`
 	Agents     []Agent     `json:"slaves"`
 	Frameworks []Framework `json:"frameworks"`
+	// Number of agents that are partitioned from the master, the state does not include these agents
+	UnreachableAgents float64 `json:"unreachable_slaves"`
+	// Fault domain of the master, available starting with Mesos 1.5
+	Domain *Domain `json:"domain"`
+}
+
+// Agents registered with the master, and the agents recovered from the registry after
+// a master failover that have not re-registered yet
+type MesosAgentsResponse struct {
+	Agents          []Agent `json:"slaves"`
+	RecoveredAgents []Agent `json:"recovered_slaves"`
 }
 
 type Leader struct {
@@ -30,20 +41,98 @@ type Agent struct {
 	Id               string    `json:"id"`
 	Pid              string    `json:"pid"`
 	Hostname         string    `json:"hostname"`
+	Port             int       `json:"port"`
 	Resources        Resources `json:"resources"`
 	UsedResources    Resources `json:"used_resources"`
 	OfferedResources Resources `json:"offered_resources"`
-	Name             string    `json:"hostname"`
 	//Calculated       CalculatedUse
-	//Attributes       Attributes `json:"attributes"`
-	Active  bool   `json:"active"`
-	Version string `json:"version"`
+	// Agent attributes, values are numbers for scalar attributes and strings for text and range attributes
+	Attributes  map[string]interface{} `json:"attributes"`
+	Active      bool                   `json:"active"`
+	Deactivated bool                   `json:"deactivated"`
+	// Resources reserved for each role and the resources available to all the roles
+	ReservedResources   map[string]Resources `json:"reserved_resources"`
+	UnreservedResources Resources            `json:"unreserved_resources"`
+	DrainInfo           *DrainInfo           `json:"drain_info"`
+	Version             string               `json:"version"`
+	// Fault domain of the agent, agents without a domain are in the domain of the master
+	Domain *Domain `json:"domain"`
 	// -------- Computed parameters
+	State            AgentState // lifecycle state of the agent
+	AccessRoles      []string   // roles that can use the resources of the agent
 	ClusterName      string
 	IP               string // parsed ip for the Slave
 	PortNum          string
+	CPUMHz           float64 // speed of each CPU in MHz
 	ResourceUseStats *CalculatedUse
 	TaskMap          map[string]*Task
+	PodMap           map[string]*Pod // task groups on the agent by pod id
+	// Usage and raw statistics of the executors that are not matched to a task or a pod,
+	// included in the agent usage so the agent totals add up
+	UnattributedUseStats *CalculatedUse
+	ExecutorStats        map[string]Statistics
+	// Recently failed and lost tasks on the agent by framework name
+	TaskFailures map[string]*TaskFailures
+	// Agent excluded by the discovery filters, discovered without actions
+	NonControllable bool
+	// Segments for the placement constraints of the apps by segment key
+	Segments map[string]*Segment
+}
+
+// Number of the recently failed and lost tasks of a framework
+type TaskFailures struct {
+	Failed int
+	Lost   int
+}
+
+// Lifecycle state of an agent
+type AgentState string
+
+const (
+	AGENT_ACTIVE      AgentState = "active"
+	AGENT_INACTIVE    AgentState = "inactive"
+	AGENT_DRAINING    AgentState = "draining"
+	AGENT_UNREACHABLE AgentState = "unreachable"
+	AGENT_MAINTENANCE AgentState = "maintenance"
+)
+
+// True if the agent is known to be down and cannot be queried for the stats
+func (agent *Agent) IsDown() bool {
+	return agent.State == AGENT_UNREACHABLE || agent.State == AGENT_MAINTENANCE
+}
+
+// Region of the agent, empty if the agent does not have a fault domain
+func (agent *Agent) Region() string {
+	if agent.Domain == nil || agent.Domain.FaultDomain == nil {
+		return ""
+	}
+	return agent.Domain.FaultDomain.Region.Name
+}
+
+// Zone of the agent, empty if the agent does not have a fault domain
+func (agent *Agent) Zone() string {
+	if agent.Domain == nil || agent.Domain.FaultDomain == nil {
+		return ""
+	}
+	return agent.Domain.FaultDomain.Zone.Name
+}
+
+type Domain struct {
+	FaultDomain *FaultDomain `json:"fault_domain"`
+}
+
+type FaultDomain struct {
+	Region DomainName `json:"region"`
+	Zone   DomainName `json:"zone"`
+}
+
+type DomainName struct {
+	Name string `json:"name"`
+}
+
+// Drain state of an agent, available starting with Mesos 1.9
+type DrainInfo struct {
+	State string `json:"state"`
 }
 
 // assumed to be framework from slave , not from master state
@@ -54,22 +143,128 @@ type Framework struct {
 	Hostname  string    `json:"hostname"`
 	Active    bool      `json:"active"`
 	Role      string    `json:"role"`
+	Roles     []string  `json:"roles"`
 	Resources Resources `json:"resources"`
 	Tasks     []Task    `json:"tasks"`
+	// Tasks on the unreachable agents and the recently completed tasks
+	UnreachableTasks []Task `json:"unreachable_tasks"`
+	CompletedTasks   []Task `json:"completed_tasks"`
 }
 
 type Task struct {
-	FrameworkId string    `json:"framework_id"`
-	SlaveId     string    `json:"slave_id"`
-	Container   Container `json:"container"`
-	Discovery   Discovery `json:"discovery"`
-	ExecutorId  string    `json:"executor_id"`
-	Id          string    `json:"id"`
-	//Labels           []Label   `json:"labels"`
-	Name      string    `json:"name"`
-	Resources Resources `json:"resources"`
-	State     string    `json:"state"`
-	//Statuses         []Status  `json:"statuses"`
+	FrameworkId string       `json:"framework_id"`
+	SlaveId     string       `json:"slave_id"`
+	Container   Container    `json:"container"`
+	Discovery   Discovery    `json:"discovery"`
+	ExecutorId  string       `json:"executor_id"`
+	Id          string       `json:"id"`
+	Labels      []TaskLabel  `json:"labels"`
+	Name        string       `json:"name"`
+	Resources   Resources    `json:"resources"`
+	State       string       `json:"state"`
+	Role        string       `json:"role"` // role of the framework if not set for the task
+	Statuses    []TaskStatus `json:"statuses"`
+	//--------- Computed Stats
+	RawStatistics    Statistics //read by querying the agent
+	ResourceUseStats *CalculatedUse
+	App              *App   // Marathon app of the task, nil if not available
+	PodId            string // id of the task group pod, empty if the task is not in a task group
+	FrameworkName    string
+	NonControllable  bool     // task excluded by the discovery filters, discovered without actions
+	SegmentKeys      []string // segments of the agents satisfying the placement constraints of the app
+}
+
+// Segment of the agents satisfying a placement constraint. The capacity limits the number of tasks of an app
+// on the agent, the segments without capacity only restrict the placement.
+type Segment struct {
+	Capacity float64
+	Used     float64
+}
+
+type TaskLabel struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type TaskStatus struct {
+	State           string          `json:"state"`
+	Timestamp       float64         `json:"timestamp"`
+	ContainerStatus ContainerStatus `json:"container_status"`
+}
+
+type ContainerStatus struct {
+	ContainerId *ContainerID `json:"container_id"`
+	// IP addresses assigned to the container on each network
+	NetworkInfos []NetworkInfo `json:"network_infos"`
+}
+
+type NetworkInfo struct {
+	Name        string      `json:"name"`
+	IPAddresses []IPAddress `json:"ip_addresses"`
+}
+
+type IPAddress struct {
+	Protocol  string `json:"protocol"`
+	IPAddress string `json:"ip_address"`
+}
+
+// Id of a container, the parent is set for the nested containers of a task group
+type ContainerID struct {
+	Value  string       `json:"value"`
+	Parent *ContainerID `json:"parent"`
+}
+
+// Id of the container for the task from the latest task status, empty if not available
+func (task *Task) ContainerId() string {
+	var latest *TaskStatus
+	for idx := range task.Statuses {
+		status := &task.Statuses[idx]
+		if status.ContainerStatus.ContainerId == nil {
+			continue
+		}
+		if latest == nil || status.Timestamp > latest.Timestamp {
+			latest = status
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.ContainerStatus.ContainerId.Value
+}
+
+// IP addresses of the container for the task from the latest task status with the network info
+func (task *Task) IPAddresses() []string {
+	var latest *TaskStatus
+	for idx := range task.Statuses {
+		status := &task.Statuses[idx]
+		if len(status.ContainerStatus.NetworkInfos) == 0 {
+			continue
+		}
+		if latest == nil || status.Timestamp > latest.Timestamp {
+			latest = status
+		}
+	}
+	var ipAddresses []string
+	if latest == nil {
+		return ipAddresses
+	}
+	for _, networkInfo := range latest.ContainerStatus.NetworkInfos {
+		for _, ipAddress := range networkInfo.IPAddresses {
+			if ipAddress.IPAddress != "" {
+				ipAddresses = append(ipAddresses, ipAddress.IPAddress)
+			}
+		}
+	}
+	return ipAddresses
+}
+
+// Group of tasks launched together by the default executor and sharing the executor container
+type Pod struct {
+	Id          string // unique id using the agent, framework and executor ids
+	ExecutorId  string
+	FrameworkId string
+	TaskMap     map[string]*Task
+	Resources   Resources
 	//--------- Computed Stats
 	RawStatistics    Statistics //read by querying the agent
 	ResourceUseStats *CalculatedUse
@@ -144,12 +339,75 @@ type App struct {
 	Constraints  [][]string `json:"constraints"`
 	RequirePorts bool       `json:"requirePorts"`
 	Container    Container  `json:"container"`
+	Tasks        []AppTask  `json:"tasks"`
+}
+
+type AppTask struct {
+	Id      string `json:"id"`
+	SlaveId string `json:"slaveId"`
+}
+
+type AppsResponse struct {
+	Apps []App `json:"apps"`
 }
 
 //// ==================== Container =================
 type Container struct {
 	Docker ContDocker `json:"docker"`
-	Type   string     `json"type"`
+	Type   string     `json:"type"` // MESOS or DOCKER containerizer
+	Mesos  *ContMesos `json:"mesos"`
+	// Networks joined by the container, the container is on the host network if not set
+	NetworkInfos []ContNetworkInfo `json:"network_infos"`
+}
+
+type ContNetworkInfo struct {
+	Name string `json:"name"`
+}
+
+// Mesos containerizer with an optional container image
+type ContMesos struct {
+	Image *ContImage `json:"image"`
+}
+
+type ContImage struct {
+	Type   string `json:"type"` // APPC or DOCKER
+	Docker *struct {
+		Name string `json:"name"`
+	} `json:"docker"`
+	Appc *struct {
+		Name string `json:"name"`
+	} `json:"appc"`
+}
+
+// Name of the image for the container, empty if the container does not use an image
+func (container *Container) Image() string {
+	if container.Docker.Image != "" {
+		return container.Docker.Image
+	}
+	if container.Mesos == nil || container.Mesos.Image == nil {
+		return ""
+	}
+	image := container.Mesos.Image
+	if image.Docker != nil {
+		return image.Docker.Name
+	}
+	if image.Appc != nil {
+		return image.Appc.Name
+	}
+	return ""
+}
+
+// Network mode for the container, HOST, BRIDGE, USER or NONE
+func (container *Container) NetworkMode() string {
+	if container.Docker.Network != "" {
+		return container.Docker.Network
+	}
+	for _, networkInfo := range container.NetworkInfos {
+		if networkInfo.Name != "" {
+			return "USER"
+		}
+	}
+	return "HOST"
 }
 
 type ContDocker struct {
@@ -166,6 +424,39 @@ type PortMapping struct {
 	ServicePort   int `json:"servicePort"`
 }
 
+// ================= Maintenance Status Rest API Response ====================
+type MaintenanceStatus struct {
+	DrainingMachines []DrainingMachine `json:"draining_machines"`
+	DownMachines     []MachineID       `json:"down_machines"`
+}
+
+type DrainingMachine struct {
+	Id MachineID `json:"id"`
+}
+
+type MachineID struct {
+	Hostname string `json:"hostname"`
+	IP       string `json:"ip"`
+}
+
+// ================= Agent Operator API GET_CONTAINERS Response ====================
+type AgentContainersResponse struct {
+	GetContainers struct {
+		Containers []AgentContainer `json:"containers"`
+	} `json:"get_containers"`
+}
+
+type AgentContainer struct {
+	FrameworkId        IDValue     `json:"framework_id"`
+	ExecutorId         IDValue     `json:"executor_id"`
+	ContainerId        ContainerID `json:"container_id"`
+	ResourceStatistics *Statistics `json:"resource_statistics"`
+}
+
+type IDValue struct {
+	Value string `json:"value"`
+}
+
 // ==============================================
 
 type TokenResponse struct {
@@ -187,5 +478,9 @@ type CalculatedUse struct {
 	Disk   float64
 	MemKB  float64
 	CPUMHz float64
+	// Peak values, available when the usage is computed from the sampled statistics
+	Sampled    bool
+	MemPeakKB  float64
+	CPUPeakMHz float64
 	//UsedPorts map[string]PortUtil
 }
@@ -0,0 +1,114 @@
+package discovery
+
+import (
+	"fmt"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Role of the frameworks that only use the unreserved resources
+	DEFAULT_ROLE string = "*"
+	// Key prefix for the access commodities representing the roles
+	ROLE_KEY_PREFIX string = "role::"
+
+	AGENT_ATTRIBUTE_NAMESPACE string = "MESOS_AGENT_ATTRIBUTE"
+	RESERVED_ROLES_PROPERTY   string = "reserved-roles"
+)
+
+// Set the roles that can use the resources of each agent.
+// The resources reserved for a role are only available to that role, while the agents with unreserved
+// resources can be used by all the roles in the cluster.
+func setAgentAccessRoles(mesosMaster *data.MesosMaster, agentList []*data.Agent) {
+	clusterRoles := map[string]bool{DEFAULT_ROLE: true}
+	for _, framework := range mesosMaster.FrameworkMap {
+		if framework.Role != "" {
+			clusterRoles[framework.Role] = true
+		}
+		for _, role := range framework.Roles {
+			clusterRoles[role] = true
+		}
+	}
+	for _, task := range mesosMaster.TaskMap {
+		clusterRoles[task.Role] = true
+	}
+
+	for _, agent := range agentList {
+		roles := make(map[string]bool)
+		for role := range agent.ReservedResources {
+			roles[role] = true
+		}
+		if hasUnreservedResources(agent) {
+			for role := range clusterRoles {
+				roles[role] = true
+			}
+		}
+		agent.AccessRoles = sortedRoles(roles)
+	}
+}
+
+// True if some of the agent resources are not reserved for a role.
+// The unreserved resources are computed from the reservations if not reported by the master.
+func hasUnreservedResources(agent *data.Agent) bool {
+	unreserved := agent.UnreservedResources
+	if unreserved.CPUUnits == 0 && unreserved.MemMB == 0 {
+		unreserved.CPUUnits, unreserved.MemMB = agent.Resources.CPUUnits, agent.Resources.MemMB
+		for _, reserved := range agent.ReservedResources {
+			unreserved.CPUUnits -= reserved.CPUUnits
+			unreserved.MemMB -= reserved.MemMB
+		}
+	}
+	return unreserved.CPUUnits > 0 || unreserved.MemMB > 0
+}
+
+func sortedRoles(roles map[string]bool) []string {
+	var roleList []string
+	for role := range roles {
+		roleList = append(roleList, role)
+	}
+	sort.Strings(roleList)
+	return roleList
+}
+
+func getRoleKey(role string) string {
+	return ROLE_KEY_PREFIX + role
+}
+
+// Properties for the agent attributes and the roles with reserved resources on the agent
+func agentProperties(agent *data.Agent) []*proto.EntityDTO_EntityProperty {
+	var properties []*proto.EntityDTO_EntityProperty
+	var names []string
+	for name := range agent.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	namespace := AGENT_ATTRIBUTE_NAMESPACE
+	for _, name := range names {
+		attrName, attrValue := name, formatAttributeValue(agent.Attributes[name])
+		properties = append(properties, &proto.EntityDTO_EntityProperty{
+			Namespace: &namespace,
+			Name:      &attrName,
+			Value:     &attrValue,
+		})
+	}
+
+	if len(agent.ReservedResources) > 0 {
+		reservedRoles := make(map[string]bool)
+		for role := range agent.ReservedResources {
+			reservedRoles[role] = true
+		}
+		properties = append(properties,
+			newEntityProperty(RESERVED_ROLES_PROPERTY, strings.Join(sortedRoles(reservedRoles), ",")))
+	}
+	return properties
+}
+
+func formatAttributeValue(value interface{}) string {
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
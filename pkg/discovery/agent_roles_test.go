@@ -0,0 +1,34 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"reflect"
+	"testing"
+)
+
+func TestSetAgentAccessRoles(t *testing.T) {
+	mesosMaster := &data.MesosMaster{
+		FrameworkMap: map[string]*data.Framework{
+			"f1": {Id: "f1", Role: "web"},
+			"f2": {Id: "f2", Roles: []string{"db"}},
+		},
+	}
+	reservedAgent := &data.Agent{
+		Id:                "reserved",
+		Resources:         data.Resources{CPUUnits: 4, MemMB: 1024},
+		ReservedResources: map[string]data.Resources{"db": {CPUUnits: 4, MemMB: 1024}},
+	}
+	sharedAgent := &data.Agent{
+		Id:                "shared",
+		Resources:         data.Resources{CPUUnits: 4, MemMB: 1024},
+		ReservedResources: map[string]data.Resources{"db": {CPUUnits: 2, MemMB: 512}},
+	}
+	setAgentAccessRoles(mesosMaster, []*data.Agent{reservedAgent, sharedAgent})
+
+	if !reflect.DeepEqual(reservedAgent.AccessRoles, []string{"db"}) {
+		t.Errorf("Unexpected roles for reserved agent %v", reservedAgent.AccessRoles)
+	}
+	if !reflect.DeepEqual(sharedAgent.AccessRoles, []string{"*", "db", "web"}) {
+		t.Errorf("Unexpected roles for shared agent %v", sharedAgent.AccessRoles)
+	}
+}
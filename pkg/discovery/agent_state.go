@@ -0,0 +1,54 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	AGENT_STATE_PROPERTY string = "agent-state"
+	// Drain state of the agents being drained, starting with Mesos 1.9
+	DRAINING_STATE string = "DRAINING"
+	DRAINED_STATE  string = "DRAINED"
+)
+
+// Lifecycle state of an agent registered with the master, using the machines scheduled for maintenance
+func getAgentState(agent *data.Agent, maintenanceStatus *data.MaintenanceStatus) data.AgentState {
+	if maintenanceStatus != nil {
+		for _, machine := range maintenanceStatus.DownMachines {
+			if isAgentMachine(agent, machine) {
+				return data.AGENT_MAINTENANCE
+			}
+		}
+		for _, machine := range maintenanceStatus.DrainingMachines {
+			if isAgentMachine(agent, machine.Id) {
+				return data.AGENT_DRAINING
+			}
+		}
+	}
+	if agent.DrainInfo != nil && (agent.DrainInfo.State == DRAINING_STATE || agent.DrainInfo.State == DRAINED_STATE) {
+		return data.AGENT_DRAINING
+	}
+	if !agent.Active || agent.Deactivated {
+		return data.AGENT_INACTIVE
+	}
+	return data.AGENT_ACTIVE
+}
+
+func isAgentMachine(agent *data.Agent, machine data.MachineID) bool {
+	if machine.Hostname != "" && machine.Hostname == agent.Hostname {
+		return true
+	}
+	return machine.IP != "" && machine.IP == agent.IP
+}
+
+// Power state of the agent VM, agents in maintenance are powered off and unreachable agents are in unknown state
+func getAgentPowerState(agent *data.Agent) proto.EntityDTO_PowerState {
+	switch agent.State {
+	case data.AGENT_MAINTENANCE:
+		return proto.EntityDTO_POWERED_OFF
+	case data.AGENT_UNREACHABLE:
+		return proto.EntityDTO_POWERSTATE_UNKNOWN
+	}
+	return proto.EntityDTO_POWERED_ON
+}
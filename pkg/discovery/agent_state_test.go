@@ -0,0 +1,29 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"testing"
+)
+
+func TestGetAgentState(t *testing.T) {
+	maintenanceStatus := &data.MaintenanceStatus{
+		DrainingMachines: []data.DrainingMachine{{Id: data.MachineID{Hostname: "agent-2"}}},
+		DownMachines:     []data.MachineID{{IP: "10.0.0.3"}},
+	}
+	testCases := []struct {
+		agent    *data.Agent
+		expected data.AgentState
+	}{
+		{&data.Agent{Hostname: "agent-1", Active: true}, data.AGENT_ACTIVE},
+		{&data.Agent{Hostname: "agent-1", Active: false}, data.AGENT_INACTIVE},
+		{&data.Agent{Hostname: "agent-1", Active: true, DrainInfo: &data.DrainInfo{State: DRAINING_STATE}}, data.AGENT_DRAINING},
+		{&data.Agent{Hostname: "agent-2", Active: true}, data.AGENT_DRAINING},
+		{&data.Agent{Hostname: "agent-3", IP: "10.0.0.3"}, data.AGENT_MAINTENANCE},
+	}
+	for _, testCase := range testCases {
+		state := getAgentState(testCase.agent, maintenanceStatus)
+		if state != testCase.expected {
+			t.Errorf("%+v: expected state %s, got %s", testCase.agent, testCase.expected, state)
+		}
+	}
+}
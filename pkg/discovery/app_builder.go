@@ -3,9 +3,12 @@ package discovery
 import (
 	"fmt"
 	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
 	"github.com/turbonomic/mesosturbo/pkg/data"
 	"github.com/turbonomic/turbo-go-sdk/pkg/builder"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"net"
+	"strconv"
 	"strings"
 )
 
@@ -13,10 +16,15 @@ var (
 	APP_ENTITY_PREFIX string = "APP-"
 )
 
+// Property with the IP:port of the application used to stitch the application with the applications discovered
+// by the APM and load balancer targets
+const PROXY_APP_ADDRESS string = "Proxy_App_Address"
+
 // Builder for creating Application Entities to represent the Mesos Tasks in Turbo server
 type AppEntityBuilder struct {
 	nodeRepository *NodeRepository
 	errorCollector *ErrorCollector
+	stitchingConf  *conf.StitchingConf
 }
 
 // Build Application EntityDTO using the tasks listed in the 'state' json returned from the Mesos Master
@@ -33,8 +41,9 @@ func (tb *AppEntityBuilder) BuildEntities() ([]*proto.EntityDTO, error) {
 			tb.errorCollector.Collect(fmt.Errorf("Null task object for entity %s", taskEntity.GetId()))
 			continue
 		}
-		if task.State != "TASK_RUNNING" {
-			tb.errorCollector.Collect(fmt.Errorf("Task object is not running %s", taskEntity.GetId()))
+		lifecycle, discovered := getTaskLifecycle(task)
+		if !discovered {
+			glog.V(3).Infof("Skipping task %s in state %s", taskEntity.GetId(), task.State)
 			continue
 		}
 
@@ -42,11 +51,20 @@ func (tb *AppEntityBuilder) BuildEntities() ([]*proto.EntityDTO, error) {
 		commoditiesSoldApp := tb.appCommsSold(task)
 		// Application Entity for the task
 		entityDTOBuilder := tb.appEntityDTO(task, commoditiesSoldApp)
+		entityDTOBuilder = tb.appStitchingData(entityDTOBuilder, task)
+		entityDTOBuilder = entityDTOBuilder.WithProperty(taskStateProperty(lifecycle)).
+			WithPowerState(getTaskPowerState(lifecycle))
+		for _, prop := range taskProperties(task) {
+			entityDTOBuilder = entityDTOBuilder.WithProperty(prop)
+		}
 		// Commodities bought
 		entityDTOBuilder = tb.appCommoditiesBought(entityDTOBuilder, taskEntity)
 		// Entity DTO
 		entityDTO, err := entityDTOBuilder.Create()
 		tb.errorCollector.Collect(err)
+		if !taskControllable(task, proto.EntityDTO_APPLICATION) {
+			setNonControllable(entityDTO)
+		}
 
 		result = append(result, entityDTO)
 	}
@@ -62,7 +80,7 @@ func (tb *AppEntityBuilder) BuildEntities() ([]*proto.EntityDTO, error) {
 // Build Application DTO
 func (tb *AppEntityBuilder) appEntityDTO(task *data.Task, commoditiesSold []*proto.CommodityDTO) *builder.EntityDTOBuilder {
 	appEntityType := proto.EntityDTO_APPLICATION
-	id := strings.Join([]string{APP_ENTITY_PREFIX, task.Name, "-", task.Id}, "")
+	id := getAppId(task)
 	dispName := strings.Join([]string{APP_ENTITY_PREFIX, task.Name}, "")
 	entityDTOBuilder := builder.NewEntityDTOBuilder(appEntityType, id).
 		DisplayName(dispName).
@@ -71,6 +89,72 @@ func (tb *AppEntityBuilder) appEntityDTO(task *data.Task, commoditiesSold []*pro
 	return entityDTOBuilder
 }
 
+// Id of the application entity for the task
+func getAppId(task *data.Task) string {
+	return strings.Join([]string{APP_ENTITY_PREFIX, task.Name, "-", task.Id}, "")
+}
+
+// Set the address of the application, and the metadata for the application to be replaced by the application
+// with the same address discovered by the APM or load balancer targets if the application stitching is enabled.
+// The address is the IP of the container, or the agent for the tasks on the host network, and the first port
+// from the task discovery info. The agent IP is shared by the tasks on the host network, so these applications
+// are stitched only if the task declares a port.
+func (tb *AppEntityBuilder) appStitchingData(entityDTOBuilder *builder.EntityDTOBuilder, task *data.Task) *builder.EntityDTOBuilder {
+	ipAddress := tb.nodeRepository.agentEntity.node.IP
+	containerIP := false
+	if ipAddresses := task.IPAddresses(); len(ipAddresses) > 0 {
+		ipAddress = ipAddresses[0]
+		containerIP = true
+	}
+	if ipAddress == "" {
+		return entityDTOBuilder
+	}
+	appData := &proto.EntityDTO_ApplicationData{
+		IpAddress: &ipAddress,
+	}
+	address := ipAddress
+	port := getAppPort(task)
+	if port != "" {
+		appData.Port = &port
+		address = net.JoinHostPort(ipAddress, port)
+	}
+	entityDTOBuilder = entityDTOBuilder.ApplicationData(appData)
+	if tb.stitchingConf == nil || !tb.stitchingConf.Applications {
+		return entityDTOBuilder
+	}
+	if !containerIP && port == "" {
+		glog.V(3).Infof("%s: no unique address for the application on the agent network", task.Name)
+		return entityDTOBuilder
+	}
+	entityDTOBuilder = entityDTOBuilder.WithProperty(newEntityProperty(PROXY_APP_ADDRESS, address))
+	metaData := generateAppReconciliationMetaData()
+	glog.V(3).Infof("%s: app stitching metadata for %s %s", task.Name, address, metaData)
+	return entityDTOBuilder.ReplacedBy(metaData)
+}
+
+// The application is replaced by the application discovered by the APM target, which gets the
+// commodities bought by the application from the container
+func generateAppReconciliationMetaData() *proto.EntityDTO_ReplacementEntityMetaData {
+	replacementEntityMetaDataBuilder := builder.NewReplacementEntityMetaDataBuilder()
+	replacementEntityMetaDataBuilder.Matching(PROXY_APP_ADDRESS)
+	replacementEntityMetaDataBuilder.
+		PatchBuying(proto.CommodityDTO_VCPU).
+		PatchBuying(proto.CommodityDTO_VMEM).
+		PatchBuying(proto.CommodityDTO_APPLICATION)
+	metaData := replacementEntityMetaDataBuilder.Build()
+	return metaData
+}
+
+// First port of the application from the task discovery info, empty if the task does not declare the ports
+func getAppPort(task *data.Task) string {
+	for _, port := range task.Discovery.Ports.Ports {
+		if port.Number > 0 {
+			return strconv.FormatInt(port.Number, 10)
+		}
+	}
+	return ""
+}
+
 // Build commodityDTOs for commodity sold by the app
 func (tb *AppEntityBuilder) appCommsSold(task *data.Task) []*proto.CommodityDTO {
 
@@ -96,11 +180,11 @@ func (tb *AppEntityBuilder) appCommoditiesBought(appDto *builder.EntityDTOBuilde
 	vMemCommBuilder := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VMEM)
 	vCpuCommBuilder := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VCPU)
 	// VMem
-	memUsed := getEntityMetricValue(taskEntity, data.MEM, data.USED, tb.errorCollector)
+	memUsed := getTaskUsedValue(taskEntity, task, data.MEM, tb.errorCollector)
 	vMemCommBuilder.Used(*memUsed)
 
 	// VCpu
-	cpuUsed := getEntityMetricValue(taskEntity, data.CPU, data.USED, tb.errorCollector)
+	cpuUsed := getTaskUsedValue(taskEntity, task, data.CPU, tb.errorCollector)
 	vCpuCommBuilder.Used(*cpuUsed)
 
 	vMemComm, err := vMemCommBuilder.Create()
@@ -0,0 +1,104 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"testing"
+)
+
+func newTaskWithIP(id, ipAddress string) *data.Task {
+	task := &data.Task{Id: id, Name: "web"}
+	if ipAddress != "" {
+		task.Statuses = []data.TaskStatus{{
+			State: "TASK_RUNNING",
+			ContainerStatus: data.ContainerStatus{NetworkInfos: []data.NetworkInfo{
+				{IPAddresses: []data.IPAddress{{IPAddress: ipAddress}}},
+			}},
+		}}
+	}
+	return task
+}
+
+func createAppStitchingDTO(t *testing.T, appBuilder *AppEntityBuilder, task *data.Task) *proto.EntityDTO {
+	entityDTOBuilder := builder.NewEntityDTOBuilder(proto.EntityDTO_APPLICATION, "app-"+task.Id)
+	entityDTO, err := appBuilder.appStitchingData(entityDTOBuilder, task).Create()
+	if err != nil {
+		t.Fatalf("Error creating app: %s", err)
+	}
+	return entityDTO
+}
+
+func getPropertyValue(entityDTO *proto.EntityDTO, name string) (string, bool) {
+	for _, prop := range entityDTO.GetEntityProperties() {
+		if prop.GetName() == name {
+			return prop.GetValue(), true
+		}
+	}
+	return "", false
+}
+
+func TestAppStitchingData(t *testing.T) {
+	nodeRepository := NewNodeRepository("a1")
+	nodeRepository.agentEntity.node = &data.Agent{Id: "a1", IP: "10.0.0.1"}
+	appBuilder := &AppEntityBuilder{
+		nodeRepository: nodeRepository,
+		stitchingConf:  &conf.StitchingConf{Applications: true},
+	}
+
+	entityDTO := createAppStitchingDTO(t, appBuilder, newTaskWithIP("t1", "9.0.1.130"))
+	if entityDTO.GetApplicationData().GetIpAddress() != "9.0.1.130" {
+		t.Errorf("Unexpected application data %v", entityDTO.GetApplicationData())
+	}
+	if address, _ := getPropertyValue(entityDTO, PROXY_APP_ADDRESS); address != "9.0.1.130" {
+		t.Errorf("Unexpected application address %s", address)
+	}
+	metaData := entityDTO.GetReplacementEntityData()
+	if metaData == nil {
+		t.Fatalf("Application should have the replacement metadata")
+	}
+	if len(metaData.GetIdentifyingProp()) != 1 || metaData.GetIdentifyingProp()[0] != PROXY_APP_ADDRESS {
+		t.Errorf("Unexpected matching properties %v", metaData.GetIdentifyingProp())
+	}
+	if len(metaData.GetBuyingCommTypes()) != 3 {
+		t.Errorf("Unexpected bought commodities %v", metaData.GetBuyingCommTypes())
+	}
+
+	// container IP and port
+	task := newTaskWithIP("t4", "9.0.1.132")
+	task.Discovery = data.Discovery{Ports: data.DiscPorts{Ports: []data.PortInfo{{Number: 8080, Protocol: "tcp"}}}}
+	entityDTO = createAppStitchingDTO(t, appBuilder, task)
+	if entityDTO.GetApplicationData().GetIpAddress() != "9.0.1.132" || entityDTO.GetApplicationData().GetPort() != "8080" {
+		t.Errorf("Unexpected application data %v", entityDTO.GetApplicationData())
+	}
+	if address, _ := getPropertyValue(entityDTO, PROXY_APP_ADDRESS); address != "9.0.1.132:8080" {
+		t.Errorf("Unexpected application address %s", address)
+	}
+
+	// task on the host network uses the agent IP
+	task = newTaskWithIP("t5", "")
+	task.Discovery = data.Discovery{Ports: data.DiscPorts{Ports: []data.PortInfo{{Number: 0}, {Number: 31005}}}}
+	entityDTO = createAppStitchingDTO(t, appBuilder, task)
+	if address, _ := getPropertyValue(entityDTO, PROXY_APP_ADDRESS); address != "10.0.0.1:31005" {
+		t.Errorf("Unexpected application address %s", address)
+	}
+	// the agent IP without a port is not unique for the application
+	entityDTO = createAppStitchingDTO(t, appBuilder, newTaskWithIP("t2", ""))
+	if entityDTO.GetApplicationData().GetIpAddress() != "10.0.0.1" {
+		t.Errorf("Unexpected application data %v", entityDTO.GetApplicationData())
+	}
+	if _, exists := getPropertyValue(entityDTO, PROXY_APP_ADDRESS); exists || entityDTO.GetReplacementEntityData() != nil {
+		t.Errorf("Application on the agent network without a port should not be stitched")
+	}
+
+	// application stitching is not enabled
+	appBuilder.stitchingConf = nil
+	entityDTO = createAppStitchingDTO(t, appBuilder, newTaskWithIP("t3", "9.0.1.131"))
+	if entityDTO.GetApplicationData().GetIpAddress() != "9.0.1.131" {
+		t.Errorf("Unexpected application data %v", entityDTO.GetApplicationData())
+	}
+	if _, exists := getPropertyValue(entityDTO, PROXY_APP_ADDRESS); exists || entityDTO.GetReplacementEntityData() != nil {
+		t.Errorf("Application should not be stitched when application stitching is not enabled")
+	}
+}
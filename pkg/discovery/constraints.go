@@ -0,0 +1,220 @@
+package discovery
+
+import (
+	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Key prefix for the segmentation commodities representing the Marathon placement constraints
+	CONSTRAINT_KEY_PREFIX string = "constraint::"
+
+	HOSTNAME_CONSTRAINT_FIELD string = "hostname"
+
+	// Marathon constraint operators
+	CLUSTER_OPERATOR  string = "CLUSTER"
+	IS_OPERATOR       string = "IS"
+	LIKE_OPERATOR     string = "LIKE"
+	UNLIKE_OPERATOR   string = "UNLIKE"
+	GROUP_BY_OPERATOR string = "GROUP_BY"
+	UNIQUE_OPERATOR   string = "UNIQUE"
+	MAX_PER_OPERATOR  string = "MAX_PER"
+)
+
+// Translate the placement constraints of the Marathon apps into the segments sold by the agents satisfying
+// the constraints and bought by the tasks of the apps.
+// The fault domain constraints are represented by the access commodities of the region and zone.
+func setConstraintSegments(mesosMaster *data.MesosMaster, agentList []*data.Agent) {
+	appTasks := make(map[*data.App][]*data.Task)
+	for _, task := range mesosMaster.TaskMap {
+		if task.App == nil || len(task.App.Constraints) == 0 {
+			continue
+		}
+		if _, exists := mesosMaster.AgentMap[task.SlaveId]; !exists {
+			continue
+		}
+		appTasks[task.App] = append(appTasks[task.App], task)
+	}
+	for app, tasks := range appTasks {
+		sort.Slice(tasks, func(i, j int) bool { return tasks[i].Id < tasks[j].Id })
+		for _, constraint := range app.Constraints {
+			if len(constraint) < 2 || strings.HasPrefix(constraint[0], "@") {
+				continue
+			}
+			setSegments(app, constraint, tasks, mesosMaster.AgentMap, agentList)
+		}
+	}
+}
+
+// Segments for one constraint - [field, operator] or [field, operator, value]
+func setSegments(app *data.App, constraint []string, tasks []*data.Task,
+	agentMap map[string]*data.Agent, agentList []*data.Agent) {
+	field, operator := constraint[0], strings.ToUpper(constraint[1])
+	var value string
+	if len(constraint) > 2 {
+		value = constraint[2]
+	}
+	switch operator {
+	case CLUSTER_OPERATOR, IS_OPERATOR:
+		// The tasks of a cluster constraint without a value are placed on the agents with the value of the first task
+		if value == "" {
+			value = constraintFieldValue(agentMap[tasks[0].SlaveId], field)
+		}
+		if value == "" {
+			return
+		}
+		key := CONSTRAINT_KEY_PREFIX + field + "=" + value
+		for _, agent := range agentList {
+			if constraintFieldValue(agent, field) == value {
+				addSegment(agent, key, 0)
+			}
+		}
+		addTaskSegmentKey(tasks, key)
+	case LIKE_OPERATOR, UNLIKE_OPERATOR:
+		pattern, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			glog.Warningf("Invalid %s constraint for app %s : %s", operator, app.Name, err)
+			return
+		}
+		key := CONSTRAINT_KEY_PREFIX + field + ":" + operator + ":" + value
+		for _, agent := range agentList {
+			if pattern.MatchString(constraintFieldValue(agent, field)) == (operator == LIKE_OPERATOR) {
+				addSegment(agent, key, 0)
+			}
+		}
+		addTaskSegmentKey(tasks, key)
+	case UNIQUE_OPERATOR, MAX_PER_OPERATOR:
+		if field != HOSTNAME_CONSTRAINT_FIELD {
+			setSpreadSegments(field, tasks, agentMap, agentList)
+			return
+		}
+		// Anti-affinity of the tasks of the app, each agent can host a limited number of tasks of the app
+		capacity := 1.0
+		if operator == MAX_PER_OPERATOR {
+			maxPer, err := strconv.Atoi(value)
+			if err != nil || maxPer < 1 {
+				glog.Warningf("Invalid %s constraint for app %s : %s", operator, app.Name, value)
+				return
+			}
+			capacity = float64(maxPer)
+		}
+		key := CONSTRAINT_KEY_PREFIX + strings.ToLower(operator) + "::" + app.Name
+		for _, agent := range agentList {
+			addSegment(agent, key, capacity)
+		}
+		for _, task := range tasks {
+			if segment, exists := agentMap[task.SlaveId].Segments[key]; exists {
+				segment.Used += 1
+			}
+		}
+		addTaskSegmentKey(tasks, key)
+	case GROUP_BY_OPERATOR:
+		setSpreadSegments(field, tasks, agentMap, agentList)
+	default:
+		glog.V(2).Infof("Unsupported %s constraint for app %s", operator, app.Name)
+	}
+}
+
+// The tasks of the apps spread across the values of a field are kept on the agents with the current value,
+// so the moves do not change the spread of the tasks
+func setSpreadSegments(field string, tasks []*data.Task, agentMap map[string]*data.Agent, agentList []*data.Agent) {
+	for _, agent := range agentList {
+		if value := constraintFieldValue(agent, field); value != "" {
+			addSegment(agent, CONSTRAINT_KEY_PREFIX+field+"="+value, 0)
+		}
+	}
+	for _, task := range tasks {
+		if value := constraintFieldValue(agentMap[task.SlaveId], field); value != "" {
+			addTaskSegmentKey([]*data.Task{task}, CONSTRAINT_KEY_PREFIX+field+"="+value)
+		}
+	}
+}
+
+// Value of the constraint field for the agent, the hostname or the value of the agent attribute
+func constraintFieldValue(agent *data.Agent, field string) string {
+	if agent == nil {
+		return ""
+	}
+	if field == HOSTNAME_CONSTRAINT_FIELD {
+		return agent.Hostname
+	}
+	if attribute, exists := agent.Attributes[field]; exists {
+		return formatAttributeValue(attribute)
+	}
+	return ""
+}
+
+func addSegment(agent *data.Agent, key string, capacity float64) {
+	if agent.Segments == nil {
+		agent.Segments = make(map[string]*data.Segment)
+	}
+	if _, exists := agent.Segments[key]; !exists {
+		agent.Segments[key] = &data.Segment{Capacity: capacity}
+	}
+}
+
+func addTaskSegmentKey(tasks []*data.Task, key string) {
+	for _, task := range tasks {
+		exists := false
+		for _, taskKey := range task.SegmentKeys {
+			exists = exists || taskKey == key
+		}
+		if !exists {
+			task.SegmentKeys = append(task.SegmentKeys, key)
+		}
+	}
+}
+
+// Segmentation commodities sold by the agent
+func segmentationCommsSold(agent *data.Agent, ec *ErrorCollector) []*proto.CommodityDTO {
+	var keys []string
+	for key := range agent.Segments {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var commodities []*proto.CommodityDTO
+	for _, key := range keys {
+		segment := agent.Segments[key]
+		commBuilder := builder.NewCommodityDTOBuilder(proto.CommodityDTO_SEGMENTATION).Key(key)
+		if segment.Capacity > 0 {
+			commBuilder = commBuilder.Capacity(segment.Capacity).Used(segment.Used)
+		}
+		comm, err := commBuilder.Create()
+		ec.Collect(err)
+		commodities = append(commodities, comm)
+	}
+	return commodities
+}
+
+// Segmentation commodities bought by the container of the task from the agent
+func segmentationCommsBought(task *data.Task, agent *data.Agent, ec *ErrorCollector) []*proto.CommodityDTO {
+	var commodities []*proto.CommodityDTO
+	for _, key := range task.SegmentKeys {
+		commBuilder := builder.NewCommodityDTOBuilder(proto.CommodityDTO_SEGMENTATION).Key(key)
+		if segment, exists := agent.Segments[key]; exists && segment.Capacity > 0 {
+			commBuilder = commBuilder.Used(1)
+		}
+		comm, err := commBuilder.Create()
+		ec.Collect(err)
+		commodities = append(commodities, comm)
+	}
+	return commodities
+}
+
+// Segmentation commodities bought by the pod from the agent, the union of the segment keys of its tasks
+func podSegmentationCommsBought(pod *data.Pod, agent *data.Agent, ec *ErrorCollector) []*proto.CommodityDTO {
+	podTask := &data.Task{}
+	for _, task := range pod.TaskMap {
+		for _, key := range task.SegmentKeys {
+			addTaskSegmentKey([]*data.Task{podTask}, key)
+		}
+	}
+	sort.Strings(podTask.SegmentKeys)
+	return segmentationCommsBought(podTask, agent, ec)
+}
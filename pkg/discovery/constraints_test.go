@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"reflect"
+	"testing"
+)
+
+func TestConstraintSegments(t *testing.T) {
+	a1 := &data.Agent{Id: "a1", Hostname: "h1", Attributes: map[string]interface{}{"rack": "r1", "gpu": "true"}}
+	a2 := &data.Agent{Id: "a2", Hostname: "h2", Attributes: map[string]interface{}{"rack": "r2"}}
+	app := &data.App{Name: "/prod/web", Constraints: [][]string{
+		{"hostname", "UNIQUE"},
+		{"rack", "GROUP_BY"},
+		{"gpu", "CLUSTER", "true"},
+		{"hostname", "LIKE", "h[0-9]"},
+		{"@zone", "GROUP_BY"},
+	}}
+	task := &data.Task{Id: "t1", SlaveId: "a1", App: app}
+	mesosMaster := &data.MesosMaster{
+		AgentMap: map[string]*data.Agent{a1.Id: a1, a2.Id: a2},
+		TaskMap:  map[string]*data.Task{task.Id: task},
+	}
+	setConstraintSegments(mesosMaster, []*data.Agent{a1, a2})
+
+	expectedKeys := []string{
+		"constraint::unique::/prod/web",
+		"constraint::rack=r1",
+		"constraint::gpu=true",
+		"constraint::hostname:LIKE:h[0-9]",
+	}
+	if !reflect.DeepEqual(task.SegmentKeys, expectedKeys) {
+		t.Errorf("Task segment keys %v, expected %v", task.SegmentKeys, expectedKeys)
+	}
+
+	unique := a1.Segments["constraint::unique::/prod/web"]
+	if unique == nil || unique.Capacity != 1 || unique.Used != 1 {
+		t.Errorf("Unexpected unique segment on a1 %+v", unique)
+	}
+	if unique := a2.Segments["constraint::unique::/prod/web"]; unique == nil || unique.Used != 0 {
+		t.Errorf("Unexpected unique segment on a2 %+v", unique)
+	}
+	if _, exists := a2.Segments["constraint::gpu=true"]; exists {
+		t.Errorf("Agent a2 without gpu should not sell the gpu segment")
+	}
+	if _, exists := a2.Segments["constraint::rack=r2"]; !exists {
+		t.Errorf("Agent a2 should sell its rack segment")
+	}
+
+	comms := segmentationCommsBought(task, a1, new(ErrorCollector))
+	if len(comms) != len(expectedKeys) || comms[0].GetUsed() != 1 || comms[1].Used != nil {
+		t.Errorf("Unexpected segmentation commodities bought %v", comms)
+	}
+}
+
+func TestPodSegmentationCommsBought(t *testing.T) {
+	agent := &data.Agent{Id: "a1", Segments: map[string]*data.Segment{
+		"constraint::rack=r1":          {},
+		"constraint::unique::/prod/db": {Capacity: 1, Used: 1},
+	}}
+	pod := &data.Pod{Id: "p1", TaskMap: map[string]*data.Task{
+		"t1": {Id: "t1", SegmentKeys: []string{"constraint::rack=r1"}},
+		"t2": {Id: "t2", SegmentKeys: []string{"constraint::rack=r1", "constraint::unique::/prod/db"}},
+	}}
+
+	comms := podSegmentationCommsBought(pod, agent, new(ErrorCollector))
+	if len(comms) != 2 {
+		t.Fatalf("Pod should buy the union of the task segment keys, got %v", comms)
+	}
+	if comms[0].GetKey() != "constraint::rack=r1" || comms[0].Used != nil {
+		t.Errorf("Unexpected rack segment bought %v", comms[0])
+	}
+	if comms[1].GetKey() != "constraint::unique::/prod/db" || comms[1].GetUsed() != 1 {
+		t.Errorf("Unexpected unique segment bought %v", comms[1])
+	}
+}
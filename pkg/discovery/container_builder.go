@@ -3,17 +3,23 @@ package discovery
 import (
 	"fmt"
 	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
 	"github.com/turbonomic/mesosturbo/pkg/data"
 	"github.com/turbonomic/turbo-go-sdk/pkg/builder"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	"github.com/turbonomic/turbo-go-sdk/pkg/supplychain"
+	"strings"
 )
 
+// Property with the IP addresses of the container for the task
+const CONTAINER_IPS_PROPERTY string = "container-ip-addresses"
+
 // Builder for creating Container Entities to represent the default container Mesos Tasks in Turbo server
 type ContainerEntityBuilder struct {
 	nodeRepository *NodeRepository
 	errorCollector *ErrorCollector
 	agent          *data.Agent
+	stitchingConf  *conf.StitchingConf
 }
 
 // Build Container EntityDTO using the tasks listed in 'state' json returned from the Mesos Master
@@ -32,9 +38,9 @@ func (cb *ContainerEntityBuilder) BuildEntities() ([]*proto.EntityDTO, error) {
 			cb.errorCollector.Collect(fmt.Errorf("Null task object for entity %s", containerEntity.GetId()))
 			continue
 		}
-		// skip non running tasks
-		if task.State != "TASK_RUNNING" {
-			cb.errorCollector.Collect(fmt.Errorf("Task object is not running %s", containerEntity.GetId()))
+		// skip completed tasks
+		if _, discovered := getTaskLifecycle(task); !discovered {
+			glog.V(3).Infof("Skipping task %s in state %s", containerEntity.GetId(), task.State)
 			continue
 		}
 
@@ -48,6 +54,9 @@ func (cb *ContainerEntityBuilder) BuildEntities() ([]*proto.EntityDTO, error) {
 		// Entity DTO
 		entityDTO, err := entityDTOBuilder.Create()
 		cb.errorCollector.Collect(err)
+		if !taskControllable(task, proto.EntityDTO_CONTAINER) {
+			setNonControllable(entityDTO)
+		}
 
 		result = append(result, entityDTO)
 	}
@@ -88,6 +97,25 @@ func (cb *ContainerEntityBuilder) buildContainerEntityDTO(task *data.Task, commo
 		Value:     &ipAddress,
 	}
 	entityDTOBuilder = entityDTOBuilder.WithProperty(ipProp)
+	linkProp, err := vmLinkProperty(cb.agent, cb.stitchingConf)
+	cb.errorCollector.Collect(err)
+	if linkProp != nil {
+		entityDTOBuilder = entityDTOBuilder.WithProperty(linkProp)
+	}
+	for _, prop := range metricsStateProperties(cb.nodeRepository.agentEntity) {
+		entityDTOBuilder = entityDTOBuilder.WithProperty(prop)
+	}
+	for _, prop := range taskProperties(task) {
+		entityDTOBuilder = entityDTOBuilder.WithProperty(prop)
+	}
+	// IP addresses of the container on the CNI or overlay networks, the agent IP is used to stitch with the VM
+	if ipAddresses := task.IPAddresses(); len(ipAddresses) > 0 {
+		entityDTOBuilder = entityDTOBuilder.WithProperty(
+			newEntityProperty(CONTAINER_IPS_PROPERTY, strings.Join(ipAddresses, ",")))
+	}
+	lifecycle, _ := getTaskLifecycle(task)
+	entityDTOBuilder = entityDTOBuilder.WithProperty(taskStateProperty(lifecycle)).
+		WithPowerState(getTaskPowerState(lifecycle))
 	glog.V(3).Infof("Container %s will be stitched to VM with IP %s", dispName, ipAddress)
 
 	return entityDTOBuilder
@@ -108,21 +136,23 @@ func (cb *ContainerEntityBuilder) containerCommsSold(containerEntity *ContainerE
 
 	// VMem
 	memCap := getEntityMetricValue(containerEntity, data.MEM, data.CAP, cb.errorCollector)
-	memUsed := getEntityMetricValue(containerEntity, data.MEM, data.USED, cb.errorCollector)
+	memUsed := getTaskUsedValue(containerEntity, task, data.MEM, cb.errorCollector)
 	vMemCommBuilder.
 		Capacity(*memCap).Used(*memUsed)
 
 	// VCpu
 	cpuCap := getEntityMetricValue(containerEntity, data.CPU, data.CAP, cb.errorCollector)
-	cpuUsed := getEntityMetricValue(containerEntity, data.CPU, data.USED, cb.errorCollector)
+	cpuUsed := getTaskUsedValue(containerEntity, task, data.CPU, cb.errorCollector)
 	vCpuCommBuilder.Capacity(*cpuCap).Used(*cpuUsed)
 
 	vMemComm, err := vMemCommBuilder.Create()
 	cb.errorCollector.Collect(err)
+	setCommodityPeak(vMemComm, containerEntity, data.MEM)
 	commoditiesSold = append(commoditiesSold, vMemComm)
 
 	vCpuComm, err := vCpuCommBuilder.Create()
 	cb.errorCollector.Collect(err)
+	setCommodityPeak(vCpuComm, containerEntity, data.CPU)
 	commoditiesSold = append(commoditiesSold, vCpuComm)
 
 	// Application with task id as the key
@@ -138,6 +168,9 @@ func (cb *ContainerEntityBuilder) containerCommsSold(containerEntity *ContainerE
 // Build commodityDTOs for commodity sold by the container
 func (cb *ContainerEntityBuilder) containerCommoditiesBought(containerDto *builder.EntityDTOBuilder, containerEntity *ContainerEntity) *builder.EntityDTOBuilder {
 	task := containerEntity.task
+	if task.PodId != "" {
+		return cb.podContainerCommoditiesBought(containerDto, containerEntity)
+	}
 
 	var commoditiesBought []*proto.CommodityDTO
 
@@ -155,11 +188,11 @@ func (cb *ContainerEntityBuilder) containerCommoditiesBought(containerDto *build
 	cpuProvCommBuilder.Used(*cpuProvUsed)
 
 	// VMem
-	memUsed := getEntityMetricValue(containerEntity, data.MEM, data.USED, cb.errorCollector)
+	memUsed := getTaskUsedValue(containerEntity, task, data.MEM, cb.errorCollector)
 	vMemCommBuilder.Used(*memUsed)
 
 	// VCpu
-	cpuUsed := getEntityMetricValue(containerEntity, data.CPU, data.USED, cb.errorCollector)
+	cpuUsed := getTaskUsedValue(containerEntity, task, data.CPU, cb.errorCollector)
 	vCpuCommBuilder.Used(*cpuUsed)
 
 	memProvComm, err := memProvCommBuilder.Create()
@@ -185,9 +218,54 @@ func (cb *ContainerEntityBuilder) containerCommoditiesBought(containerDto *build
 	cb.errorCollector.Collect(err)
 	commoditiesBought = append(commoditiesBought, clusterCommBought)
 
+	// Role of the task and the region and zone for the tasks of the apps with fault domain constraints,
+	// the container can only be placed on the agents with resources for the role in the same fault domain
+	for _, key := range taskAccessKeys(task, cb.agent) {
+		accessCommBought, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
+			Key(key).
+			Create()
+		cb.errorCollector.Collect(err)
+		commoditiesBought = append(commoditiesBought, accessCommBought)
+	}
+
+	// Placement constraints of the app of the task
+	commoditiesBought = append(commoditiesBought, segmentationCommsBought(task, cb.agent, cb.errorCollector)...)
+
 	providerDto := builder.CreateProvider(proto.EntityDTO_VIRTUAL_MACHINE, task.SlaveId)
 	containerDto.Provider(providerDto)
 	containerDto.BuysCommodities(commoditiesBought)
 
 	return containerDto
 }
+
+// Build commodityDTOs for commodity bought by the container for a task in a task group from the pod
+func (cb *ContainerEntityBuilder) podContainerCommoditiesBought(containerDto *builder.EntityDTOBuilder, containerEntity *ContainerEntity) *builder.EntityDTOBuilder {
+	task := containerEntity.task
+
+	var commoditiesBought []*proto.CommodityDTO
+
+	// VMem
+	memUsed := getTaskUsedValue(containerEntity, task, data.MEM, cb.errorCollector)
+	vMemComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VMEM).Used(*memUsed).Create()
+	cb.errorCollector.Collect(err)
+	commoditiesBought = append(commoditiesBought, vMemComm)
+
+	// VCpu
+	cpuUsed := getTaskUsedValue(containerEntity, task, data.CPU, cb.errorCollector)
+	vCpuComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VCPU).Used(*cpuUsed).Create()
+	cb.errorCollector.Collect(err)
+	commoditiesBought = append(commoditiesBought, vCpuComm)
+
+	// Pod of the task group
+	podComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
+		Key(getPodKey(task.PodId)).
+		Create()
+	cb.errorCollector.Collect(err)
+	commoditiesBought = append(commoditiesBought, podComm)
+
+	providerDto := builder.CreateProvider(proto.EntityDTO_CONTAINER_POD, task.PodId)
+	containerDto.Provider(providerDto)
+	containerDto.BuysCommodities(commoditiesBought)
+
+	return containerDto
+}
@@ -0,0 +1,85 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"testing"
+)
+
+func TestContainerIPsProperty(t *testing.T) {
+	agent := &data.Agent{Id: "a1", IP: "10.0.0.1"}
+	nodeRepository := NewNodeRepository("a1")
+	nodeRepository.agentEntity.node = agent
+	containerBuilder := &ContainerEntityBuilder{
+		nodeRepository: nodeRepository,
+		errorCollector: new(ErrorCollector),
+		agent:          agent,
+	}
+
+	task := newTaskWithIP("t1", "9.0.1.130")
+	task.SlaveId = "a1"
+	entityDTO, err := containerBuilder.buildContainerEntityDTO(task, nil).Create()
+	if err != nil {
+		t.Fatalf("Error creating container: %s", err)
+	}
+	if ips, _ := getPropertyValue(entityDTO, CONTAINER_IPS_PROPERTY); ips != "9.0.1.130" {
+		t.Errorf("Unexpected container IP addresses %s", ips)
+	}
+
+	task = newTaskWithIP("t2", "")
+	task.SlaveId = "a1"
+	entityDTO, err = containerBuilder.buildContainerEntityDTO(task, nil).Create()
+	if err != nil {
+		t.Fatalf("Error creating container: %s", err)
+	}
+	if _, exists := getPropertyValue(entityDTO, CONTAINER_IPS_PROPERTY); exists {
+		t.Errorf("Container without IP addresses should not have the IP addresses property")
+	}
+}
+
+func TestStagingTaskWithoutUsage(t *testing.T) {
+	task := &data.Task{Id: "t1", Name: "web", SlaveId: "a1", State: TASK_STAGING,
+		Resources: data.Resources{CPUUnits: 1, MemMB: 256}}
+	agent := &data.Agent{Id: "a1", IP: "10.0.0.1", CPUMHz: 2400, TaskMap: map[string]*data.Task{task.Id: task}}
+	nodeRepository := NewNodeRepository(agent.Id)
+	nodeRepository.agentEntity.node = agent
+	taskEntity := nodeRepository.CreateTaskEntity(task.Id)
+	taskEntity.task = task
+	containerEntity := nodeRepository.CreateContainerEntity(task.Id)
+	containerEntity.task = task
+
+	// the executor of the staging task has no stats yet
+	monitor := NewDefaultMesosMonitor(&conf.MesosTargetConf{})
+	monitoringProps := createMonitoringProps(nodeRepository, NewMesosMetricsMetadataStore().metricDefMap)
+	ec := new(ErrorCollector)
+	monitor.setTaskMetrics(nodeRepository.GetTaskEntities(), monitoringProps, ec)
+	monitor.setContainerMetrics(agent, nodeRepository.GetContainerEntities(), monitoringProps, ec)
+	if ec.Count() > 0 {
+		t.Fatalf("Unexpected monitor errors %s", ec)
+	}
+
+	containerBuilder := &ContainerEntityBuilder{nodeRepository: nodeRepository}
+	containers, err := containerBuilder.BuildEntities()
+	if err != nil {
+		t.Errorf("Unexpected container errors for the staging task : %s", err)
+	}
+	if len(containers) != 1 {
+		t.Fatalf("Expected 1 container, got %d", len(containers))
+	}
+	for _, comm := range containers[0].GetCommoditiesSold() {
+		if comm.GetUsed() != 0 {
+			t.Errorf("Unexpected %s used %f for the staging task", comm.GetCommodityType(), comm.GetUsed())
+		}
+	}
+
+	appBuilder := &AppEntityBuilder{nodeRepository: nodeRepository}
+	if _, err := appBuilder.BuildEntities(); err != nil {
+		t.Errorf("Unexpected application errors for the staging task : %s", err)
+	}
+
+	// running task without stats is still reported as missing usage
+	task.State = TASK_RUNNING
+	if _, err := containerBuilder.BuildEntities(); err == nil {
+		t.Errorf("Expected missing usage errors for the running task")
+	}
+}
@@ -0,0 +1,122 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/mesosturbo/pkg/prometheus"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolves the speed in MHz of the CPUs of an agent using the host overrides, agent attribute
+// and Prometheus query in the config, in that order, and then the default CPU speed.
+// Values returned by the Prometheus query are saved since the CPU speed does not change.
+type CPUFrequencyResolver struct {
+	cpuFrequencyConf *conf.CPUFrequencyConf
+	client           *prometheus.PrometheusClient
+
+	lock         sync.Mutex
+	queryResults map[string]float64
+}
+
+func NewCPUFrequencyResolver(targetConf *conf.MesosTargetConf) *CPUFrequencyResolver {
+	resolver := &CPUFrequencyResolver{
+		cpuFrequencyConf: targetConf.CPUFrequency,
+		queryResults:     make(map[string]float64),
+	}
+	if resolver.cpuFrequencyConf == nil {
+		resolver.cpuFrequencyConf = &conf.CPUFrequencyConf{
+			Attribute: conf.DEFAULT_CPU_MHZ_ATTRIBUTE,
+		}
+	}
+	prometheusConf := targetConf.Prometheus
+	if resolver.cpuFrequencyConf.PrometheusQuery != "" && prometheusConf != nil {
+		timeout := time.Duration(prometheusConf.TimeoutSecs) * time.Second
+		resolver.client = prometheus.NewPrometheusClient(prometheusConf.Url, timeout)
+	}
+	return resolver
+}
+
+// Get the CPU speed in MHz for the agent
+func (resolver *CPUFrequencyResolver) GetCPUMHz(ctx context.Context, agent *data.Agent) float64 {
+	if resolver == nil {
+		return data.CPU_MULTIPLIER
+	}
+	cpuFrequencyConf := resolver.cpuFrequencyConf
+	if mhz, exists := cpuFrequencyConf.HostOverrides[agent.Hostname]; exists {
+		return mhz
+	}
+	if mhz, exists := cpuFrequencyConf.HostOverrides[agent.IP]; exists {
+		return mhz
+	}
+
+	mhz, err := getAttributeValue(agent, cpuFrequencyConf.Attribute)
+	if err == nil && mhz > 0 {
+		return mhz
+	}
+	if err != nil {
+		glog.Warningf("%s : Invalid cpu speed attribute : %s", agent.IP, err)
+	}
+
+	if resolver.client != nil {
+		mhz, err = resolver.queryCPUMHz(ctx, agent)
+		if err == nil && mhz > 0 {
+			return mhz
+		}
+		if err != nil {
+			glog.Warningf("%s : Error getting cpu speed from prometheus : %s", agent.IP, err)
+		}
+	}
+
+	if cpuFrequencyConf.DefaultMHz > 0 {
+		return cpuFrequencyConf.DefaultMHz
+	}
+	return data.CPU_MULTIPLIER
+}
+
+func (resolver *CPUFrequencyResolver) queryCPUMHz(ctx context.Context, agent *data.Agent) (float64, error) {
+	resolver.lock.Lock()
+	mhz, exists := resolver.queryResults[agent.Id]
+	resolver.lock.Unlock()
+	if exists {
+		return mhz, nil
+	}
+
+	replacer := strings.NewReplacer(
+		AGENT_IP_PLACEHOLDER, agent.IP,
+		AGENT_HOSTNAME_PLACEHOLDER, agent.Hostname,
+		AGENT_ID_PLACEHOLDER, agent.Id)
+	samples, err := resolver.client.Query(ctx, replacer.Replace(resolver.cpuFrequencyConf.PrometheusQuery))
+	if err != nil {
+		return data.DEFAULT_VAL, err
+	}
+	if len(samples) == 0 {
+		return data.DEFAULT_VAL, fmt.Errorf("no samples for agent %s", agent.Id)
+	}
+	mhz = samples[0].Value
+
+	resolver.lock.Lock()
+	resolver.queryResults[agent.Id] = mhz
+	resolver.lock.Unlock()
+	return mhz, nil
+}
+
+// Get the numeric value for the agent attribute, returns 0 if the agent does not have the attribute
+func getAttributeValue(agent *data.Agent, name string) (float64, error) {
+	value, exists := agent.Attributes[name]
+	if !exists {
+		return data.DEFAULT_VAL, nil
+	}
+	switch typedValue := value.(type) {
+	case float64:
+		return typedValue, nil
+	case string:
+		return strconv.ParseFloat(typedValue, 64)
+	}
+	return data.DEFAULT_VAL, fmt.Errorf("unsupported value %v for attribute %s", value, name)
+}
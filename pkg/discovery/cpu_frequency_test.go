@@ -0,0 +1,77 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCPUFrequencyResolver(t *testing.T) {
+	queries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		if r.URL.Query().Get("query") != `cpu_mhz{instance="10.0.0.3"}` {
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+			return
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[0,"2600"]}]}}`)
+	}))
+	defer server.Close()
+
+	targetConf := &conf.MesosTargetConf{
+		CPUFrequency: &conf.CPUFrequencyConf{
+			DefaultMHz:      1800,
+			HostOverrides:   map[string]float64{"host1": 3000, "10.0.0.2": 3100},
+			Attribute:       conf.DEFAULT_CPU_MHZ_ATTRIBUTE,
+			PrometheusQuery: `cpu_mhz{instance="$agent_ip"}`,
+		},
+		Prometheus: &conf.PrometheusConf{Url: server.URL, TimeoutSecs: 1},
+	}
+	resolver := NewCPUFrequencyResolver(targetConf)
+
+	tests := []struct {
+		name     string
+		agent    *data.Agent
+		expected float64
+	}{
+		{"hostname override before attribute",
+			&data.Agent{Id: "a1", Hostname: "host1", IP: "10.0.0.1",
+				Attributes: map[string]interface{}{conf.DEFAULT_CPU_MHZ_ATTRIBUTE: 2000.0}}, 3000},
+		{"ip override",
+			&data.Agent{Id: "a2", Hostname: "host2", IP: "10.0.0.2"}, 3100},
+		{"numeric attribute before prometheus",
+			&data.Agent{Id: "a3", Hostname: "host3", IP: "10.0.0.3",
+				Attributes: map[string]interface{}{conf.DEFAULT_CPU_MHZ_ATTRIBUTE: 2200.0}}, 2200},
+		{"string attribute",
+			&data.Agent{Id: "a4", Hostname: "host4", IP: "10.0.0.4",
+				Attributes: map[string]interface{}{conf.DEFAULT_CPU_MHZ_ATTRIBUTE: "2300"}}, 2300},
+		{"prometheus",
+			&data.Agent{Id: "a3", Hostname: "host3", IP: "10.0.0.3"}, 2600},
+		{"invalid attribute falls back to prometheus",
+			&data.Agent{Id: "a3", Hostname: "host3", IP: "10.0.0.3",
+				Attributes: map[string]interface{}{conf.DEFAULT_CPU_MHZ_ATTRIBUTE: "fast"}}, 2600},
+		{"default",
+			&data.Agent{Id: "a5", Hostname: "host5", IP: "10.0.0.5"}, 1800},
+	}
+	for _, test := range tests {
+		if mhz := resolver.GetCPUMHz(context.Background(), test.agent); mhz != test.expected {
+			t.Errorf("%s: expected %f, got %f", test.name, test.expected, mhz)
+		}
+	}
+	// the prometheus result for agent a3 is saved and reused
+	if queries != 2 {
+		t.Errorf("Expected 2 prometheus queries, got %d", queries)
+	}
+
+	var nilResolver *CPUFrequencyResolver
+	if mhz := nilResolver.GetCPUMHz(context.Background(), &data.Agent{}); mhz != data.CPU_MULTIPLIER {
+		t.Errorf("Expected the CPU multiplier for the nil resolver, got %f", mhz)
+	}
+	if mhz := NewCPUFrequencyResolver(&conf.MesosTargetConf{}).GetCPUMHz(context.Background(), &data.Agent{}); mhz != data.CPU_MULTIPLIER {
+		t.Errorf("Expected the CPU multiplier without config, got %f", mhz)
+	}
+}
@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"context"
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/turbonomic/mesosturbo/pkg/conf"
@@ -13,6 +14,14 @@ import (
 type DefaultMesosMonitor struct {
 	DebugMode  bool
 	DebugProps map[string]string
+	// Resolves the CPU speed of the agents, the default CPU speed is used if not set
+	cpuFrequency *CPUFrequencyResolver
+}
+
+func NewDefaultMesosMonitor(targetConf *conf.MesosTargetConf) *DefaultMesosMonitor {
+	return &DefaultMesosMonitor{
+		cpuFrequency: NewCPUFrequencyResolver(targetConf),
+	}
 }
 
 func (monitor *DefaultMesosMonitor) GetSourceName() MONITOR_NAME {
@@ -20,7 +29,7 @@ func (monitor *DefaultMesosMonitor) GetSourceName() MONITOR_NAME {
 }
 
 // Implementation method for metric collection using Mesos Agent Rest API
-func (monitor *DefaultMesosMonitor) Monitor(target *MonitorTarget) error {
+func (monitor *DefaultMesosMonitor) Monitor(ctx context.Context, target *MonitorTarget) error {
 	// Monitoring related
 	if target == nil || target.config == nil {
 		nerr := fmt.Errorf("%s: Invalid target for monitor %s", monitor.GetSourceName(), target)
@@ -52,28 +61,60 @@ func (monitor *DefaultMesosMonitor) Monitor(target *MonitorTarget) error {
 	// Get the stats from each agent and parse and save in the Agent and Task objects
 	agentEntity := nodeRepository.agentEntity
 	agent := agentEntity.node
-	arrOfExec, err := monitor.getAgentStats(agent, masterConf)
-	glog.V(3).Infof("Parsed executors %s\n", arrOfExec)
-	if err != nil {
-		nerr := fmt.Errorf("Error obtaining metrics from the agent %s::%s", agent.IP, agent.PortNum)
-		glog.Errorf("%s:%s", nerr.Error(), err)
-		return nerr
-	}
+	agent.CPUMHz = monitor.cpuFrequency.GetCPUMHz(ctx, agent)
+	glog.V(3).Infof("%s : cpu speed %f MHz", agent.IP, agent.CPUMHz)
+	// Stats are not collected for the agents known to be down, the agent and its tasks have no usage
+	if agent.IsDown() {
+		glog.V(2).Infof("%s : Skip agent stats in %s state", agent.Id, agent.State)
+		setDownAgentUsage(agent)
+	} else {
+		arrOfExec, err := monitor.getAgentStats(ctx, agent, masterConf)
+		glog.V(3).Infof("Parsed executors %s\n", arrOfExec)
+		if err != nil {
+			nerr := fmt.Errorf("Error obtaining metrics from the agent %s::%s", agent.IP, agent.PortNum)
+			glog.Errorf("%s:%s", nerr.Error(), err)
+			return nerr
+		}
 
-	err = monitor.parseAgentUsedStats(agent, arrOfExec, target.rawStatsCache)
-	if err != nil {
-		nerr := fmt.Errorf("Error parsing metrics from the agent %s::%s", agent.IP, agent.PortNum)
-		glog.Errorf("%s:%s", nerr.Error(), err)
-		return nerr
+		// Statistics for the tasks in the task groups
+		var nestedContainers []data.AgentContainer
+		if len(agent.PodMap) > 0 {
+			nestedContainers, err = monitor.getAgentContainers(ctx, agent, masterConf)
+			if err != nil {
+				glog.Warningf("%s : Error obtaining task group container stats, pod tasks will not have usage : %s", agent.IP, err)
+			}
+		}
+
+		err = monitor.parseAgentUsedStats(agent, arrOfExec, nestedContainers, target.rawStatsCache, target.sampler)
+		if err != nil {
+			nerr := fmt.Errorf("Error parsing metrics from the agent %s::%s", agent.IP, agent.PortNum)
+			glog.Errorf("%s:%s", nerr.Error(), err)
+			return nerr
+		}
 	}
 
 	// And then compute the metric values for the specified metrics and invoke the metric setter to set it in the entity
 	errorCollector := new(ErrorCollector)
 	monitor.setNodeMetrics(nodeRepository.agentEntity, target.monitoringProps, errorCollector)
 	monitor.setTaskMetrics(nodeRepository.taskEntities, target.monitoringProps, errorCollector)
-	monitor.setContainerMetrics(nodeRepository.containerEntities, target.monitoringProps, errorCollector)
+	monitor.setContainerMetrics(agent, nodeRepository.containerEntities, target.monitoringProps, errorCollector)
+	monitor.setPodMetrics(agent, nodeRepository.podEntities, target.monitoringProps, errorCollector)
 
-	return errorCollector
+	if errorCollector.Count() > 0 {
+		return errorCollector
+	}
+	return nil
+}
+
+// Zero usage for the agent that is down and its tasks and task groups
+func setDownAgentUsage(agent *data.Agent) {
+	agent.ResourceUseStats = &data.CalculatedUse{}
+	for _, task := range agent.TaskMap {
+		task.ResourceUseStats = &data.CalculatedUse{}
+	}
+	for _, pod := range agent.PodMap {
+		pod.ResourceUseStats = &data.CalculatedUse{}
+	}
 }
 
 // From - http://stackoverflow.com/questions/33470649/combine-multiple-error-strings
@@ -116,16 +157,16 @@ func (monitor *DefaultMesosMonitor) setNodeMetrics(nodeEntity *AgentEntity, moni
 		return
 	}
 
-	cpuCapMHZ := agent.Resources.CPUUnits * data.CPU_MULTIPLIER
+	cpuCapMHZ := agent.Resources.CPUUnits * agent.CPUMHz
 	setValue(nodeEntity, &cpuCapMHZ, CPU_CAP, props, ec)
 
 	memCapKB := agent.Resources.MemMB * data.KB_MULTIPLIER
 	setValue(nodeEntity, &memCapKB, MEM_CAP, props, ec)
 
-	cpuProvCapMHZ := agent.Resources.CPUUnits * data.CPU_MULTIPLIER
+	cpuProvCapMHZ := agent.Resources.CPUUnits * agent.CPUMHz
 	setValue(nodeEntity, &cpuProvCapMHZ, CPU_PROV_CAP, props, ec)
 
-	cpuProvUsedMHZ := agent.UsedResources.CPUUnits * data.CPU_MULTIPLIER
+	cpuProvUsedMHZ := agent.UsedResources.CPUUnits * agent.CPUMHz
 	setValue(nodeEntity, &cpuProvUsedMHZ, CPU_PROV_USED, props, ec)
 
 	memProvCapKB := agent.Resources.MemMB * data.KB_MULTIPLIER
@@ -137,6 +178,10 @@ func (monitor *DefaultMesosMonitor) setNodeMetrics(nodeEntity *AgentEntity, moni
 	if agent.ResourceUseStats != nil { // from the Agent Rest api
 		setValue(nodeEntity, &agent.ResourceUseStats.CPUMHz, CPU_USED, props, ec)
 		setValue(nodeEntity, &agent.ResourceUseStats.MemKB, MEM_USED, props, ec)
+		if agent.ResourceUseStats.Sampled {
+			setValue(nodeEntity, &agent.ResourceUseStats.CPUPeakMHz, CPU_PEAK, props, ec)
+			setValue(nodeEntity, &agent.ResourceUseStats.MemPeakKB, MEM_PEAK, props, ec)
+		}
 	} else {
 		glog.Errorf("Missing stats for agent %s", agent.Id)
 	}
@@ -164,7 +209,7 @@ func (monitor *DefaultMesosMonitor) setTaskMetrics(taskEntities map[string]*Task
 	return
 }
 
-func (monitor *DefaultMesosMonitor) setContainerMetrics(containerEntities map[string]*ContainerEntity, monitoringProps map[ENTITY_ID]*EntityMonitoringProps, ec *ErrorCollector) {
+func (monitor *DefaultMesosMonitor) setContainerMetrics(agent *data.Agent, containerEntities map[string]*ContainerEntity, monitoringProps map[ENTITY_ID]*EntityMonitoringProps, ec *ErrorCollector) {
 	// For each container
 	for _, containerEntity := range containerEntities {
 		var props *EntityMonitoringProps
@@ -179,7 +224,7 @@ func (monitor *DefaultMesosMonitor) setContainerMetrics(containerEntities map[st
 		// VCPU Capacity
 
 		var cpuCap float64
-		cpuCap = task.Resources.CPUUnits * data.CPU_MULTIPLIER
+		cpuCap = task.Resources.CPUUnits * agent.CPUMHz
 		setValue(containerEntity, &cpuCap, CPU_CAP, props, ec)
 
 		// VMem Capacity
@@ -192,12 +237,16 @@ func (monitor *DefaultMesosMonitor) setContainerMetrics(containerEntities map[st
 			setValue(containerEntity, &task.ResourceUseStats.CPUMHz, CPU_USED, props, ec)
 			// VMem Used
 			setValue(containerEntity, &task.ResourceUseStats.MemKB, MEM_USED, props, ec)
-		} else {
+			if task.ResourceUseStats.Sampled {
+				setValue(containerEntity, &task.ResourceUseStats.CPUPeakMHz, CPU_PEAK, props, ec)
+				setValue(containerEntity, &task.ResourceUseStats.MemPeakKB, MEM_PEAK, props, ec)
+			}
+		} else if task.State == TASK_RUNNING {
 			glog.Errorf("missing stats for container %s", task.Id)
 		}
 
 		var cpuProvUsedMHZ float64
-		cpuProvUsedMHZ = task.Resources.CPUUnits * data.CPU_MULTIPLIER
+		cpuProvUsedMHZ = task.Resources.CPUUnits * agent.CPUMHz
 		setValue(containerEntity, &cpuProvUsedMHZ, CPU_PROV_USED, props, ec)
 
 		var memProvUsedKB float64
@@ -207,6 +256,41 @@ func (monitor *DefaultMesosMonitor) setContainerMetrics(containerEntities map[st
 	return
 }
 
+func (monitor *DefaultMesosMonitor) setPodMetrics(agent *data.Agent, podEntities map[string]*PodEntity, monitoringProps map[ENTITY_ID]*EntityMonitoringProps, ec *ErrorCollector) {
+	// For each task group
+	for _, podEntity := range podEntities {
+		var props *EntityMonitoringProps
+		props, exists := monitoringProps[ENTITY_ID(podEntity.GetId())]
+		if !exists {
+			ec.Collect(fmt.Errorf("%s::%s : Missing monitoring properties", podEntity.GetType(), podEntity.GetId()))
+			continue
+		}
+
+		pod := podEntity.pod
+		// Capacity is the executor limit
+		cpuCap := pod.Resources.CPUUnits * agent.CPUMHz
+		setValue(podEntity, &cpuCap, CPU_CAP, props, ec)
+		memCapKB := pod.Resources.MemMB * data.KB_MULTIPLIER
+		setValue(podEntity, &memCapKB, MEM_CAP, props, ec)
+
+		if pod.ResourceUseStats != nil { // from the Agent Rest api
+			setValue(podEntity, &pod.ResourceUseStats.CPUMHz, CPU_USED, props, ec)
+			setValue(podEntity, &pod.ResourceUseStats.MemKB, MEM_USED, props, ec)
+			if pod.ResourceUseStats.Sampled {
+				setValue(podEntity, &pod.ResourceUseStats.CPUPeakMHz, CPU_PEAK, props, ec)
+				setValue(podEntity, &pod.ResourceUseStats.MemPeakKB, MEM_PEAK, props, ec)
+			}
+		} else {
+			glog.Errorf("missing stats for pod %s", pod.Id)
+		}
+
+		cpuProvUsedMHZ := pod.Resources.CPUUnits * agent.CPUMHz
+		setValue(podEntity, &cpuProvUsedMHZ, CPU_PROV_USED, props, ec)
+		memProvUsedKB := pod.Resources.MemMB * data.KB_MULTIPLIER
+		setValue(podEntity, &memProvUsedKB, MEM_PROV_USED, props, ec)
+	}
+}
+
 // Helper method to set value for a metric
 func setValue(entity MesosEntity, value *float64, propKey PropKey, props *EntityMonitoringProps, ec *ErrorCollector) {
 	propMap := props.propMap
@@ -238,7 +322,7 @@ func setValue(entity MesosEntity, value *float64, propKey PropKey, props *Entity
 // Get the data containing the monitoring statistics for the specified agent.
 // Create the rest api client for querying the agent and execute the stats query.
 //
-func (monitor *DefaultMesosMonitor) getAgentStats(agent *data.Agent, masterConf *conf.MasterConf) ([]data.Executor, error) {
+func (monitor *DefaultMesosMonitor) getAgentStats(ctx context.Context, agent *data.Agent, masterConf *conf.MasterConf) ([]data.Executor, error) {
 	// Create the client for making rest api queries to the agent
 	agentConf := &conf.AgentConf{
 		AgentIP:   agent.IP,
@@ -263,26 +347,42 @@ func (monitor *DefaultMesosMonitor) getAgentStats(agent *data.Agent, masterConf
 
 	// Query response with the Task statistics
 	var arrOfExec []data.Executor
-	arrOfExec, err := agentClient.GetStats()
+	arrOfExec, err := agentClient.GetStats(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return arrOfExec, nil
 }
 
+// Get the statistics for the containers on the agent including the nested containers of the task groups
+func (monitor *DefaultMesosMonitor) getAgentContainers(ctx context.Context, agent *data.Agent, masterConf *conf.MasterConf) ([]data.AgentContainer, error) {
+	if monitor.DebugMode {
+		return nil, nil
+	}
+	agentConf := &conf.AgentConf{
+		AgentIP:   agent.IP,
+		AgentPort: agent.PortNum,
+	}
+	agentClient := master.GetAgentRestClient(masterConf.Master, agentConf, masterConf)
+	return agentClient.GetContainers(ctx)
+}
+
 // Get the node cpu and mem usage metrics using the response of executor objects
-func (monitor *DefaultMesosMonitor) parseAgentUsedStats(agent *data.Agent, arrOfExec []data.Executor, rawStatsCache *RawStatsCache) error {
+// If the sampler is configured, the usage is computed using the samples collected since the last discovery.
+// The executor of a task group or a custom executor running several tasks is attributed to the pod, and the usage
+// of the tasks in the group is computed using the statistics for the nested containers if available, or else
+// split from the executor usage. The usage of the executors that are not matched to a task or a pod
+// is accounted as unattributed usage of the agent.
+func (monitor *DefaultMesosMonitor) parseAgentUsedStats(agent *data.Agent, arrOfExec []data.Executor, nestedContainers []data.AgentContainer, rawStatsCache *RawStatsCache, sampler *StatsSampler) error {
 	if arrOfExec == nil || len(arrOfExec) == 0 {
 		return fmt.Errorf("Null or empty stats response for agent %s", agent.Id)
 	}
 
-	//rawStatsCache.lastDiscoveryTime
+	// time of the last completed discovery of the agent
 	var lastTime *time.Time
 	var taskPrevStats map[string]*TaskStatistics
 	if rawStatsCache != nil {
-		if rawStatsCache.lastDiscoveryTime != nil {
-			lastTime = rawStatsCache.lastDiscoveryTime
-		}
+		lastTime = rawStatsCache.getNodeDiscoveryTime(agent.Id)
 		agentStats, exists := rawStatsCache.nodeStats[agent.Id]
 		if exists {
 			taskPrevStats = agentStats.taskStats //lastCycleStats.TaskStats
@@ -291,6 +391,15 @@ func (monitor *DefaultMesosMonitor) parseAgentUsedStats(agent *data.Agent, arrOf
 
 	// Create new ResourceUseStats for the agent
 	agent.ResourceUseStats = &data.CalculatedUse{}
+	agent.ExecutorStats = make(map[string]data.Statistics)
+	currTime := time.Now()
+	usage := &usageCalculator{
+		agent:         agent,
+		taskPrevStats: taskPrevStats,
+		lastTime:      lastTime,
+		currTime:      currTime,
+		sampler:       sampler,
+	}
 
 	// Iterate over the list and compute the task vcpu and vmem used values
 	// The used values for the agent is the sum of the used for each task
@@ -299,38 +408,38 @@ func (monitor *DefaultMesosMonitor) parseAgentUsedStats(agent *data.Agent, arrOf
 	for idx, _ := range arrOfExec {
 		executor := arrOfExec[idx]
 		glog.V(3).Infof("---------> Executor %+v\n", executor)
-		task := findTask(executor.Source, executor.Id, agent.TaskMap)
-		//
-		if task == nil { // check for the task object corresponding to this taskId
-			glog.Warningf("unknown task id %s", executor.Source+"::"+executor.Id)
+		var currStats data.Statistics
+		currStats = executor.Statistics
+
+		// Executor shared by the tasks of a task group
+		if pod := findPod(&executor, agent); pod != nil {
+			pod.RawStatistics = currStats //save for next cycle
+			pod.ResourceUseStats = usage.computeUse(pod.Id, currStats)
+			usage.addAgentUse(pod.ResourceUseStats)
+			pod.Resources.MemMB = currStats.MemLimitBytes / (data.KB_MULTIPLIER * data.KB_MULTIPLIER)
+			pod.Resources.CPUUnits = currStats.CPUsLimit
+			glog.V(3).Infof("%s::%s : Pod resource stats: [capacity %+v] [usage %+v]\n", agent.IP, pod.Id, pod.Resources, pod.ResourceUseStats)
+			continue
+		}
+
+		task := findTask(&executor, agent.TaskMap)
+		// Usage of the executor is accounted on the agent without a consumer
+		if task == nil {
+			executorKey := getExecutorKey(&executor)
+			glog.V(2).Infof("%s : unattributed executor %s for source %s", agent.IP, executorKey, executor.Source)
+			agent.ExecutorStats[executorKey] = currStats //save for next cycle
+			executorUse := usage.computeUse(executorKey, currStats)
+			usage.addAgentUse(executorUse)
+			usage.addUnattributedUse(executorUse)
 			continue
 		}
 		glog.V(3).Infof("Task %s::%s\n", task.Name, task.Id)
-		var currStats, prevStats data.Statistics
-		currStats = executor.Statistics
 		task.RawStatistics = currStats //save for next cycle
 		glog.V(3).Infof("Initial Task: [capacity %+v] [usage %+v]\n", task.Resources, task.RawStatistics)
-		if taskPrevStats != nil {
-			_, ok := taskPrevStats[task.Id]
-			if ok {
-				prevStats = taskPrevStats[task.Id].rawStats
-			} else {
-				glog.V(3).Infof("Previous cycle stats not available for " + agent.Id + "::" + task.Id)
-			}
-		}
-		usedCPUFraction := calculateCPU(task.Id, agent.Id, &prevStats, &currStats, lastTime)
-
-		usedCPU := usedCPUFraction * agent.Resources.CPUUnits * float64(1000) //CPU_MULTIPLIER
-		agent.ResourceUseStats.CPUMHz += usedCPU                              // save the accumulated value in the agent
-		glog.V(3).Infof("%s usedCPU=%f agent=%f", agent.IP, usedCPU, agent.ResourceUseStats.CPUMHz)
-		usedMemBytes := currStats.MemRSSBytes
-		usedMemKB := usedMemBytes / data.KB_MULTIPLIER
-		agent.ResourceUseStats.MemKB += usedMemKB // save in the agent
-		glog.V(3).Infof("%s usedMemBytes=%f agent=%f", agent.IP, usedMemBytes, agent.ResourceUseStats.MemKB)
+
 		// Task capacities - create new ResourceUseStats for the task
-		task.ResourceUseStats = &data.CalculatedUse{}
-		task.ResourceUseStats.CPUMHz = usedCPU // save in the task
-		task.ResourceUseStats.MemKB = usedMemKB
+		task.ResourceUseStats = usage.computeUse(task.Id, currStats)
+		usage.addAgentUse(task.ResourceUseStats)
 		task.Resources.MemMB = currStats.MemLimitBytes / (data.KB_MULTIPLIER * data.KB_MULTIPLIER)
 		task.Resources.CPUUnits = currStats.CPUsLimit
 		//task.Resources.Disk = currStats.DiskLimitBytes / float64(1024.00*1024.00)
@@ -338,25 +447,115 @@ func (monitor *DefaultMesosMonitor) parseAgentUsedStats(agent *data.Agent, arrOf
 		glog.V(3).Infof("%s::%s : Task resource stats: [capacity %+v] [usage %+v]\n", agent.IP, task.Name, task.Resources, task.ResourceUseStats)
 
 	} // task loop
+
+	// Tasks in the task groups using the nested container statistics, already accounted in the pod usage
+	for idx := range nestedContainers {
+		container := &nestedContainers[idx]
+		if container.ResourceStatistics == nil {
+			continue
+		}
+		pod, exists := agent.PodMap[getPodId(agent.Id, container.FrameworkId.Value, container.ExecutorId.Value)]
+		if !exists {
+			continue
+		}
+		task := findNestedTask(container, pod)
+		if task == nil {
+			glog.V(3).Infof("%s : unknown nested container %s in pod %s", agent.IP, container.ContainerId.Value, pod.Id)
+			continue
+		}
+		currStats := *container.ResourceStatistics
+		task.RawStatistics = currStats //save for next cycle
+		task.ResourceUseStats = usage.computeUse(task.Id, currStats)
+		glog.V(3).Infof("%s::%s : Pod task resource stats: [capacity %+v] [usage %+v]\n", agent.IP, task.Name, task.Resources, task.ResourceUseStats)
+	}
+	// Tasks of the custom executors and the task groups without the nested container statistics
+	for _, pod := range agent.PodMap {
+		splitPodUse(pod)
+	}
+	if agent.UnattributedUseStats != nil {
+		glog.V(2).Infof("%s : Agent unattributed usage %+v", agent.IP, agent.UnattributedUseStats)
+	}
 	//fmt.Printf("Agent resource stats: [capacity %+v] [usage %+v]\n", agent.Resources, agent.ResourceUseStats)
 	glog.V(2).Infof("%s : Agent resource stats: [capacity %+v] [usage %+v]\n", agent.IP, agent.Resources, agent.ResourceUseStats)
 	glog.V(3).Infof("--------------------------------------------------")
 	return nil
 }
 
-// Find the task using the sourceId or executorId from the agents stats query response
-func findTask(sourceId, executorId string, taskMap map[string]*data.Task) *data.Task {
-	var task *data.Task
-	task, ok := taskMap[sourceId]
-	if ok {
-		return task
-	}
-	for _, task := range taskMap {
-		if task.ExecutorId == executorId {
-			return task
+// Computes the usage for the tasks and pods on an agent using the current and previous cycle statistics
+type usageCalculator struct {
+	agent         *data.Agent
+	taskPrevStats map[string]*TaskStatistics
+	lastTime      *time.Time
+	currTime      time.Time
+	sampler       *StatsSampler
+}
+
+// Usage for the task or pod with the given id
+func (usage *usageCalculator) computeUse(id string, currStats data.Statistics) *data.CalculatedUse {
+	agent := usage.agent
+	var prevStats *data.Statistics
+	if usage.taskPrevStats != nil {
+		_, ok := usage.taskPrevStats[id]
+		if ok {
+			prevStats = &usage.taskPrevStats[id].rawStats
+		} else {
+			glog.V(3).Infof("Previous cycle stats not available for " + agent.Id + "::" + id)
 		}
 	}
-	return nil
+	usedCPUFraction := calculateCPU(id, agent.Id, prevStats, &currStats, usage.lastTime)
+	usedMemKB := currStats.MemRSSBytes / data.KB_MULTIPLIER
+
+	resourceUseStats := &data.CalculatedUse{}
+
+	// Usage using the samples collected in the background since the last discovery
+	var sampledUse *SampledUse
+	sampler := usage.sampler
+	if sampler != nil {
+		sampler.AddSample(agent.Id, id, usage.currTime, currStats)
+		sampledUse = sampler.GetSampledUse(agent.Id, id, usage.lastTime)
+	}
+	if sampledUse != nil {
+		glog.V(3).Infof("%s::%s : sampled usage %+v", agent.IP, id, sampledUse)
+		usedCPUFraction, usedMemKB = sampler.usedValues(sampledUse)
+		resourceUseStats.Sampled = true
+		resourceUseStats.CPUPeakMHz = sampledUse.CPUPeak * agent.CPUMHz
+		resourceUseStats.MemPeakKB = sampledUse.MemPeakKB
+	}
+
+	// number of CPUs used times the speed of each CPU
+	resourceUseStats.CPUMHz = usedCPUFraction * agent.CPUMHz
+	resourceUseStats.MemKB = usedMemKB
+	return resourceUseStats
+}
+
+// Save the accumulated usage in the agent
+func (usage *usageCalculator) addAgentUse(use *data.CalculatedUse) {
+	agent := usage.agent
+	agent.ResourceUseStats.CPUMHz += use.CPUMHz
+	glog.V(3).Infof("%s usedCPU=%f agent=%f", agent.IP, use.CPUMHz, agent.ResourceUseStats.CPUMHz)
+	agent.ResourceUseStats.MemKB += use.MemKB
+	glog.V(3).Infof("%s usedMemKB=%f agent=%f", agent.IP, use.MemKB, agent.ResourceUseStats.MemKB)
+	// Sum of the task peaks is the upper bound for the agent peak
+	if use.Sampled {
+		agent.ResourceUseStats.Sampled = true
+		agent.ResourceUseStats.CPUPeakMHz += use.CPUPeakMHz
+		agent.ResourceUseStats.MemPeakKB += use.MemPeakKB
+	}
+}
+
+// Save the accumulated usage of the executors not matched to a task or a pod in the agent
+func (usage *usageCalculator) addUnattributedUse(use *data.CalculatedUse) {
+	agent := usage.agent
+	if agent.UnattributedUseStats == nil {
+		agent.UnattributedUseStats = &data.CalculatedUse{}
+	}
+	agent.UnattributedUseStats.CPUMHz += use.CPUMHz
+	agent.UnattributedUseStats.MemKB += use.MemKB
+	if use.Sampled {
+		agent.UnattributedUseStats.Sampled = true
+		agent.UnattributedUseStats.CPUPeakMHz += use.CPUPeakMHz
+		agent.UnattributedUseStats.MemPeakKB += use.MemPeakKB
+	}
 }
 
 func calculateCPU(taskId, agentId string, prevStats, currStats *data.Statistics, lastTime *time.Time) float64 {
@@ -0,0 +1,40 @@
+package discovery
+
+import (
+	"context"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"testing"
+)
+
+func TestMonitorDownAgent(t *testing.T) {
+	task := &data.Task{Id: "t1", SlaveId: "agent-1", Resources: data.Resources{CPUUnits: 1, MemMB: 256}}
+	agent := &data.Agent{
+		Id:        "agent-1",
+		IP:        "10.0.0.1",
+		State:     data.AGENT_UNREACHABLE,
+		Resources: data.Resources{CPUUnits: 4, MemMB: 4096},
+		TaskMap:   map[string]*data.Task{task.Id: task},
+	}
+	nodeRepository := NewNodeRepository(agent.Id)
+	nodeRepository.agentEntity.node = agent
+	nodeRepository.CreateTaskEntity(task.Id).task = task
+	containerEntity := nodeRepository.CreateContainerEntity(task.Id)
+	containerEntity.task = task
+
+	// the agent is not queried, the request would fail for the agent address
+	monitor := NewDefaultMesosMonitor(&conf.MesosTargetConf{})
+	err := monitor.Monitor(context.Background(), &MonitorTarget{
+		targetId:        agent.IP,
+		config:          &conf.MasterConf{},
+		repository:      nodeRepository,
+		monitoringProps: createMonitoringProps(nodeRepository, NewMesosMetricsMetadataStore().metricDefMap),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected monitor error for the down agent : %s", err)
+	}
+	for _, entity := range []MesosEntity{nodeRepository.agentEntity, containerEntity} {
+		checkMetricValue(t, entity, data.CPU, 0)
+		checkMetricValue(t, entity, data.MEM, 0)
+	}
+}
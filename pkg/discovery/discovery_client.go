@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"context"
 	"github.com/golang/glog"
 
 	"github.com/turbonomic/turbo-go-sdk/pkg/probe"
@@ -9,8 +10,11 @@ import (
 	"fmt"
 	"github.com/turbonomic/mesosturbo/pkg/conf"
 	"github.com/turbonomic/mesosturbo/pkg/data"
+	master "github.com/turbonomic/mesosturbo/pkg/masterapi"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -28,14 +32,30 @@ type MesosDiscoveryClient struct {
 	mesosMaster         *data.MesosMaster
 	prevCycleStatsCache *RawStatsCache
 	agentList           []*data.Agent
+	// Background sampler for the agent stats, nil if sampling is not enabled
+	sampler *StatsSampler
+	// Monitors used to collect the metrics for the agents
+	monitorGroup *MonitorGroup
+	// Last known good metrics for the agents, nil if disabled
+	lastKnownMetrics *LastKnownMetricsCache
+	// Pool bounding the number of agents discovered concurrently
+	pool *AgentTaskPool
+	// Strategy and count used to group the agents into discovery workers
+	workerStrategy SelectionStrategy
+	workerCount    int
+	// Time taken to discover each agent in the previous cycle
+	agentLatency map[string]time.Duration
+	// Filters for the agents and tasks, nil if all the agents and tasks are discovered
+	filter *DiscoveryFilter
 }
 
 type SelectionStrategy string
 
 const (
-	FIXED_AGENT_SIZE     SelectionStrategy = "Fixed_Agent_Size"
-	FIXED_WORKER_SIZE    SelectionStrategy = "Fixed_Worker_Size"
-	ONE_WORKER_PER_AGENT SelectionStrategy = "One_Worker_Per_Agent"
+	FIXED_AGENT_SIZE     SelectionStrategy = SelectionStrategy(conf.FixedAgentSize)
+	FIXED_WORKER_SIZE    SelectionStrategy = SelectionStrategy(conf.FixedWorkerSize)
+	ONE_WORKER_PER_AGENT SelectionStrategy = SelectionStrategy(conf.OneWorkerPerAgent)
+	ADAPTIVE_LATENCY     SelectionStrategy = SelectionStrategy(conf.AdaptiveLatency)
 )
 
 type DiscoveryWorkerStrategy interface {
@@ -50,6 +70,8 @@ func (discoveryClient *MesosDiscoveryClient) CreateDiscoveryWorker(st SelectionS
 		agentSelector = NewFixedGroupAgentSelector(count)
 	} else if st == FIXED_WORKER_SIZE {
 		agentSelector = NewFixedWorkerSizeSelector(count)
+	} else if st == ADAPTIVE_LATENCY {
+		agentSelector = NewLatencyAgentSelector(count, discoveryClient.agentLatency)
 	} else {
 		agentSelector = &SimpleAgentSelector{}
 	}
@@ -66,9 +88,18 @@ func (discoveryClient *MesosDiscoveryClient) CreateDiscoveryWorker(st SelectionS
 	}
 
 	workerGroup = make([]*DiscoveryWorker, 0, len(agentGroups))
+	agentTimeout := time.Duration(discoveryClient.targetConf.AgentTimeoutSecs) * time.Second
 	for i, _ := range agentGroups {
 		agentList := agentGroups[i]
-		discoveryWorker := NewDiscoveryWorker(discoveryClient.MesosLeader.leaderConf, agentList, discoveryClient.prevCycleStatsCache)
+		var agentIds []string
+		for _, agent := range agentList {
+			agentIds = append(agentIds, agent.Id)
+		}
+		// copy of the previous stats so the cache can be refreshed while agents that missed the deadline are running
+		rawStatsCache := CreateCopy(discoveryClient.prevCycleStatsCache, agentIds)
+		discoveryWorker := NewDiscoveryWorker(discoveryClient.MesosLeader.leaderConf, agentList,
+			rawStatsCache, discoveryClient.sampler, discoveryClient.monitorGroup, agentTimeout, discoveryClient.pool,
+			discoveryClient.targetConf.Stitching)
 		name := fmt.Sprintf("DW-%d", i)
 		discoveryWorker.SetName(name)
 		workerGroup = append(workerGroup, discoveryWorker)
@@ -96,6 +127,43 @@ func NewDiscoveryClient(mesosMasterType conf.MesosMasterType, targetConf *conf.M
 
 	// Monitoring metadata
 	client.metricsStore = NewMesosMetricsMetadataStore()
+
+	// Raw stats saved before the last restart
+	if statsCache := targetConf.StatsCache; statsCache != nil {
+		maxAge := time.Duration(statsCache.MaxAgeSecs) * time.Second
+		rawStatsCache, err := LoadRawStatsCache(targetConf.MasterIPPort, statsCache.File, maxAge)
+		if err != nil {
+			glog.Warningf("[MesosDiscoveryClient] Ignoring stats cache %s : %s", statsCache.File, err)
+		} else {
+			glog.Infof("[MesosDiscoveryClient] Loaded stats cache %s from %s", statsCache.File, rawStatsCache.lastDiscoveryTime)
+			client.prevCycleStatsCache = rawStatsCache
+		}
+	}
+
+	filter, err := NewDiscoveryFilter(targetConf.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("Error while creating new MesosDiscoveryClient: %s", err)
+	}
+	client.filter = filter
+
+	client.pool = NewAgentTaskPool(targetConf.MaxConcurrentAgents, targetConf.WorkerConcurrency)
+	if targetConf.Sampling != nil && targetConf.Sampling.Enabled {
+		client.sampler = NewStatsSampler(targetConf.Sampling, client.pool)
+	}
+
+	monitors, err := DefaultMonitorRegistry.CreateMonitors(targetConf)
+	if err != nil {
+		return nil, fmt.Errorf("Error while creating new MesosDiscoveryClient: %s", err)
+	}
+	client.monitorGroup = NewMonitorGroup(monitors, targetConf.MonitorExecution, targetConf.FillMissingMetrics)
+	if targetConf.LastKnownMetricsCycles > 0 {
+		client.lastKnownMetrics = NewLastKnownMetricsCache(targetConf.LastKnownMetricsCycles)
+		client.monitorGroup.SetLastKnownMetricsCache(client.lastKnownMetrics)
+	}
+
+	client.workerStrategy = SelectionStrategy(targetConf.WorkerStrategy)
+	client.workerCount = targetConf.WorkerCount
+	client.agentLatency = make(map[string]time.Duration)
 	return client, nil
 }
 
@@ -154,26 +222,55 @@ func (discoveryClient *MesosDiscoveryClient) Discover(accountValues []*proto.Acc
 	}
 	glog.V(3).Infof("Mesos get succeeded: %v\n", mesosLeader.MasterState)
 
+	// Deadline for the discovery, the response is created using the agents discovered before the deadline
+	discoveryTimeout := time.Duration(discoveryClient.targetConf.DiscoveryTimeoutSecs) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+
+	mesosLeader.RefreshMaintenanceStatus(ctx)
+	mesosLeader.RefreshRecoveredAgents(ctx)
+
 	// to create convenience maps for slaves, tasks, convert units
-	mesosMaster, err := discoveryClient.parseMesosState(mesosLeader.MasterState)
+	mesosMaster, err := discoveryClient.parseMesosState(mesosLeader.MasterState, mesosLeader.MaintenanceStatus,
+		mesosLeader.RecoveredAgents)
 	if mesosMaster == nil {
 		return nil, fmt.Errorf("Error parsing mesos master response : %s", err)
 	}
+	// Marathon apps for the app constraints of the tasks
+	if marathonClient := master.NewMarathonClient(discoveryClient.targetConf, mesosLeader.leaderConf); marathonClient != nil {
+		apps, err := marathonClient.GetApps(ctx)
+		if err != nil {
+			glog.Warningf("Error getting apps from Marathon, app constraints will not be used : %s", err)
+		} else {
+			setTaskApps(mesosMaster, apps)
+			setConstraintSegments(mesosMaster, discoveryClient.agentList)
+		}
+	}
 	logMesosSummary(mesosMaster)
 	discoveryClient.mesosMaster = mesosMaster
 
+	if discoveryClient.lastKnownMetrics != nil {
+		discoveryClient.lastKnownMetrics.RetainAgents(discoveryClient.agentList)
+	}
+
+	// Sample the current set of agents in the background until the next discovery
+	if discoveryClient.sampler != nil {
+		discoveryClient.sampler.UpdateAgents(mesosLeader.leaderConf, discoveryClient.agentList)
+		discoveryClient.sampler.Start()
+	}
+
 	// Start discovery worker routines per group of agents
 	workerResponseQueue := make(chan DiscoveryWorkerResponse, 1)
 	var slice []DiscoveryWorkerResponse
 	wg := new(sync.WaitGroup)
 
 	var discoveryWorkerGroup []*DiscoveryWorker
-	discoveryWorkerGroup = discoveryClient.CreateDiscoveryWorker(FIXED_WORKER_SIZE, 10)
+	discoveryWorkerGroup = discoveryClient.CreateDiscoveryWorker(discoveryClient.workerStrategy, discoveryClient.workerCount)
 	for idx, _ := range discoveryWorkerGroup {
 		wg.Add(1)
 		go func(idx int) {
 			discoveryWorker := discoveryWorkerGroup[idx]
-			nodeResponse := discoveryWorker.DoWork()
+			nodeResponse := discoveryWorker.DoWork(ctx)
 			workerResponseQueue <- nodeResponse //send it on the channel/queue
 		}(idx)
 	}
@@ -185,16 +282,47 @@ func (discoveryClient *MesosDiscoveryClient) Discover(accountValues []*proto.Acc
 	}()
 	wg.Wait()
 
-	// Build discovery response
-	discoveryResponse, err := discoveryClient.createDiscoveryResponse(slice)
-	// Save discovery stats
-	discoveryClient.prevCycleStatsCache.RefreshCache(mesosMaster)
+	// Build discovery response, the agents that could not be discovered are reported as errors
+	discoveryResponse := discoveryClient.createDiscoveryResponse(slice)
+	discoveryResponse.DiscoveredGroup = buildGroupDTOs(mesosMaster, discoveryResponse.EntityDTO,
+		discoveryClient.targetConf.MasterIPPort)
+	// Save discovery stats for the agents discovered in time
+	var completedAgents []*data.Agent
+	agentLatency := make(map[string]time.Duration)
+	for _, workerResponse := range slice {
+		for _, agentResponse := range workerResponse {
+			agentLatency[agentResponse.agent.Id] = agentResponse.elapsed
+			if !agentResponse.timedOut {
+				completedAgents = append(completedAgents, agentResponse.agent)
+			}
+		}
+	}
+	// agents that did not respond before the deadline are the slowest
+	for _, agent := range discoveryClient.agentList {
+		if _, exists := agentLatency[agent.Id]; !exists {
+			agentLatency[agent.Id] = discoveryTimeout
+		}
+	}
+	discoveryClient.agentLatency = agentLatency
+	if len(completedAgents) < len(discoveryClient.agentList) {
+		glog.Warningf("Discovered %d of %d agents before the deadline", len(completedAgents), len(discoveryClient.agentList))
+	}
+	discoveryClient.prevCycleStatsCache.RefreshAgentStats(completedAgents)
+	discoveryClient.prevCycleStatsCache.RetainAgents(discoveryClient.agentList)
+	discoveryClient.pool.GetMetrics().log()
+	if statsCache := discoveryClient.targetConf.StatsCache; statsCache != nil {
+		err := discoveryClient.prevCycleStatsCache.Save(discoveryClient.targetConf.MasterIPPort, statsCache.File)
+		if err != nil {
+			glog.Errorf("[MesosDiscoveryClient] Error saving stats cache %s : %s", statsCache.File, err)
+		}
+	}
 	glog.Infof("%s : End discovery using leader %++v", accountValues, discoveryClient.MesosLeader.leaderConf)
 	return discoveryResponse, nil
 }
 
 // Parses the mesos state into agent and task objects and maps
-func (handler *MesosDiscoveryClient) parseMesosState(stateResp *data.MesosAPIResponse) (*data.MesosMaster, error) {
+func (handler *MesosDiscoveryClient) parseMesosState(stateResp *data.MesosAPIResponse, maintenanceStatus *data.MaintenanceStatus,
+	recoveredAgents []data.Agent) (*data.MesosMaster, error) {
 	if stateResp.Agents == nil {
 		nerr := fmt.Errorf("Error getting agents data from Mesos Master")
 		glog.Errorf("%s", nerr.Error())
@@ -206,6 +334,9 @@ func (handler *MesosDiscoveryClient) parseMesosState(stateResp *data.MesosAPIRes
 		Leader: stateResp.Leader,
 		Pid:    stateResp.Pid,
 	}
+	filter := handler.filter
+	// Agents omitted by the discovery filters
+	filteredAgents := make(map[string]bool)
 	// Agent Map
 	mesosMaster.AgentMap = make(map[string]*data.Agent)
 	handler.agentList = []*data.Agent{}
@@ -213,12 +344,35 @@ func (handler *MesosDiscoveryClient) parseMesosState(stateResp *data.MesosAPIRes
 		agent := stateResp.Agents[idx]
 		agent.ClusterName = handler.targetConf.MasterIPPort //Using target scope as cluster scope to handle
 		// deployments where the Cluster is not named
-		glog.V(3).Infof("Agent : %s Id: %s", agent.Name+"::"+agent.Pid, agent.Id)
+		glog.V(3).Infof("Agent : %s Id: %s", agent.Hostname+"::"+agent.Pid, agent.Id)
 		agent.IP, agent.PortNum = getSlaveIP(agent)
+		agent.State = getAgentState(&agent, maintenanceStatus)
+		if !filterAgent(filter, &agent, filteredAgents) {
+			continue
+		}
 		mesosMaster.AgentMap[agent.Id] = &agent
 		handler.agentList = append(handler.agentList, &agent)
 	}
 
+	// Agents that are not registered with the master are discovered as unreachable
+	for _, agent := range handler.unreachableAgents(stateResp, recoveredAgents) {
+		if _, exists := mesosMaster.AgentMap[agent.Id]; exists {
+			continue
+		}
+		if !handler.fillUnreachableAgent(agent) {
+			glog.Warningf("Skipping unreachable agent %s without address", agent.Id)
+			continue
+		}
+		if !filterAgent(filter, agent, filteredAgents) {
+			continue
+		}
+		glog.V(2).Infof("Unreachable Agent : %s Id: %s", agent.Hostname+"::"+agent.Pid, agent.Id)
+		mesosMaster.AgentMap[agent.Id] = agent
+		handler.agentList = append(handler.agentList, agent)
+	}
+
+	setAgentRegions(stateResp.Domain, handler.agentList)
+
 	// Cluster
 	mesosMaster.Cluster.MasterIP = stateResp.Leader
 	// Note - Using target scope as cluster scope to handle  deployments where the Cluster is not named
@@ -240,17 +394,45 @@ func (handler *MesosDiscoveryClient) parseMesosState(stateResp *data.MesosAPIRes
 		glog.V(3).Infof("Framework : ", framework.Name+"::"+framework.Hostname)
 		mesosMaster.FrameworkMap[framework.Id] = &framework
 
-		if framework.Tasks == nil {
+		if framework.Tasks == nil && framework.UnreachableTasks == nil {
 			glog.V(3).Infof("	No tasks defined for framework : %s", framework.Name)
 			continue
 		}
-		ftasks := framework.Tasks
+		// Tasks on the unreachable agents are discovered in unknown state
+		var ftasks []data.Task
+		ftasks = append(ftasks, framework.Tasks...)
+		for _, task := range framework.UnreachableTasks {
+			if task.State == "" {
+				task.State = TASK_UNREACHABLE
+			}
+			ftasks = append(ftasks, task)
+		}
 		for idx := range ftasks {
 			task := ftasks[idx]
-			glog.V(3).Infof("	Task : %s", task.Name)
+			glog.V(3).Infof("	Task : %s %s", task.Name, task.State)
+			task.FrameworkName = framework.Name
+			if task.Role == "" {
+				task.Role = framework.Role
+			}
+			if task.Role == "" {
+				task.Role = DEFAULT_ROLE
+			}
+			if filteredAgents[task.SlaveId] {
+				continue
+			}
+			if filter.TaskFiltered(&task) {
+				if !filter.KeepsFiltered() {
+					glog.V(3).Infof("	Task %s is excluded by the discovery filters", task.Name)
+					continue
+				}
+				task.NonControllable = true
+			}
 			mesosMaster.TaskMap[task.Id] = &task
 			taskAgent, ok := mesosMaster.AgentMap[task.SlaveId] //save in the Agent
 			if ok {
+				if taskAgent.NonControllable {
+					task.NonControllable = true
+				}
 				var taskMap map[string]*data.Task
 				if taskAgent.TaskMap == nil {
 					taskMap = make(map[string]*data.Task)
@@ -259,20 +441,91 @@ func (handler *MesosDiscoveryClient) parseMesosState(stateResp *data.MesosAPIRes
 				taskMap = taskAgent.TaskMap
 				taskMap[task.Id] = &task
 			} else {
-				glog.Warningf("Cannot find Agent: %s for task %s", taskAgent.Id+"::"+taskAgent.Pid, task.Name)
+				glog.Warningf("Cannot find Agent: %s for task %s", task.SlaveId, task.Name)
 			}
 		}
 		glog.V(3).Infof("[MesosDiscoveryClient] Number of tasks in framework %s is %d", framework.Name, len(framework.Tasks))
 
 	}
+	setAgentAccessRoles(mesosMaster, handler.agentList)
+	setAgentTaskFailures(stateResp.Frameworks, mesosMaster.AgentMap, time.Now())
+	for _, agent := range handler.agentList {
+		setAgentPods(agent)
+	}
 	return mesosMaster, nil
 }
 
+// Apply the discovery filters to the agent, returns false if the agent is omitted from the discovery
+func filterAgent(filter *DiscoveryFilter, agent *data.Agent, filteredAgents map[string]bool) bool {
+	if !filter.AgentFiltered(agent) {
+		return true
+	}
+	if !filter.KeepsFiltered() {
+		glog.V(2).Infof("Agent %s::%s is excluded by the discovery filters", agent.Id, agent.Hostname)
+		filteredAgents[agent.Id] = true
+		return false
+	}
+	agent.NonControllable = true
+	return true
+}
+
+// Agents that are not registered with the master - the agents recovered from the registry after a master failover
+// that have not re-registered yet, and the agents of the unreachable tasks. The master state only has the number
+// of the unreachable agents, so only the id is known for these agents.
+func (handler *MesosDiscoveryClient) unreachableAgents(stateResp *data.MesosAPIResponse, recoveredAgents []data.Agent) []*data.Agent {
+	var agents []*data.Agent
+	agentIds := make(map[string]bool)
+	for idx := range recoveredAgents {
+		agent := recoveredAgents[idx]
+		agentIds[agent.Id] = true
+		agents = append(agents, &agent)
+	}
+	if stateResp.UnreachableAgents == 0 {
+		return agents
+	}
+	for _, framework := range stateResp.Frameworks {
+		for _, task := range framework.UnreachableTasks {
+			if task.SlaveId == "" || agentIds[task.SlaveId] {
+				continue
+			}
+			agentIds[task.SlaveId] = true
+			agents = append(agents, &data.Agent{Id: task.SlaveId})
+		}
+	}
+	return agents
+}
+
+// Complete the agent that is not registered with the master using the agent info from the previous discovery.
+// The recovered agents have the agent info without the pid, the agent hostname and port are used as the address
+// if the agent was not discovered before. Returns false if the agent address cannot be resolved.
+func (handler *MesosDiscoveryClient) fillUnreachableAgent(agent *data.Agent) bool {
+	if handler.mesosMaster != nil {
+		if prevAgent, exists := handler.mesosMaster.AgentMap[agent.Id]; exists {
+			agent.Pid = prevAgent.Pid
+			if agent.Hostname == "" { // only the id is known for the agents of the unreachable tasks
+				agent.Hostname = prevAgent.Hostname
+				agent.Resources = prevAgent.Resources
+				agent.Attributes = prevAgent.Attributes
+				agent.ReservedResources = prevAgent.ReservedResources
+				agent.UnreservedResources = prevAgent.UnreservedResources
+				agent.Domain = prevAgent.Domain
+			}
+		}
+	}
+	agent.ClusterName = handler.targetConf.MasterIPPort
+	agent.IP, agent.PortNum = getSlaveIP(*agent)
+	if agent.IP == "" && agent.Hostname != "" && agent.Port > 0 {
+		agent.IP, agent.PortNum = agent.Hostname, strconv.Itoa(agent.Port)
+	}
+	agent.State = data.AGENT_UNREACHABLE
+	return agent.IP != ""
+}
+
 func logMesosSummary(mesosMaster *data.MesosMaster) {
 	glog.Infof("Master Id:%s, Pid:%s, Leader:%s, Cluster:%+v", mesosMaster.Id, mesosMaster.Pid, mesosMaster.Leader, mesosMaster.Cluster)
 
 	for _, agent := range mesosMaster.AgentMap {
-		glog.V(2).Infof("Agent Id: %s, Name: %s, IP: %s, Number of tasks is %d", agent.Id, agent.Name, agent.IP, len(agent.TaskMap))
+		glog.V(2).Infof("Agent Id: %s, Name: %s, IP: %s, State: %s, Number of tasks is %d", agent.Id, agent.Hostname, agent.IP, agent.State, len(agent.TaskMap))
 		for _, task := range agent.TaskMap {
 			glog.V(4).Infof("	Task Id: %s, Name: %s", task.Id, task.Name)
 		}
@@ -291,29 +544,72 @@ func logMesosSummary(mesosMaster *data.MesosMaster) {
 	}
 }
 
-func (client *MesosDiscoveryClient) createDiscoveryResponse(workerResponseList []DiscoveryWorkerResponse) (*proto.DiscoveryResponse, error) {
+func (client *MesosDiscoveryClient) createDiscoveryResponse(workerResponseList []DiscoveryWorkerResponse) *proto.DiscoveryResponse {
 	var entityDtos []*proto.EntityDTO
-	ec := new(ErrorCollector)
+	var errorDtos []*proto.ErrorDTO
+	respondedAgents := make(map[string]bool)
 	for i, _ := range workerResponseList {
 		nodeResponseList := workerResponseList[i]
 		for j, _ := range nodeResponseList {
 			nodeResponse := nodeResponseList[j]
-			if nodeResponse != nil && len(nodeResponse.entityDTOs) > 0 {
+			if nodeResponse == nil {
+				continue
+			}
+			agent := nodeResponse.agent
+			respondedAgents[agent.Id] = true
+			if len(nodeResponse.entityDTOs) > 0 {
 				entityDtos = append(entityDtos, nodeResponse.entityDTOs...)
+			} else if !nodeResponse.timedOut {
+				errorDtos = append(errorDtos,
+					createAgentErrorDTO(agent, fmt.Errorf("Null DTOs for agent %s::%s", agent.Id, agent.IP)))
 			}
-			if nodeResponse != nil && len(nodeResponse.entityDTOs) == 0 {
-				ec.Collect(fmt.Errorf("Null DTOs for agent %s::%s", nodeResponse.agent.Id, nodeResponse.agent.IP))
+			if nodeResponse.errors != nil {
+				for _, err := range *nodeResponse.errors {
+					errorDtos = append(errorDtos, createAgentErrorDTO(agent, err))
+				}
 			}
-
-			ec.Collect(nodeResponse.errors)
 		}
 	}
+	// agents whose worker did not respond before the deadline
+	for _, agent := range client.agentList {
+		if !respondedAgents[agent.Id] {
+			errorDtos = append(errorDtos,
+				createAgentErrorDTO(agent, fmt.Errorf("Agent not discovered before the deadline")))
+		}
+	}
+	if len(errorDtos) > 0 {
+		glog.Warningf("[MesosDiscoveryClient] %d errors discovering the agents", len(errorDtos))
+	}
 
+	// Datacenter hosting the physical machines of the agents in the standalone mode
+	if isStandalone(client.targetConf.Stitching) && len(entityDtos) > 0 {
+		dcDto, err := buildDatacenterEntity(client.targetConf.MasterIPPort)
+		if err != nil {
+			glog.Errorf("[MesosDiscoveryClient] Error creating datacenter entity : %s", err)
+		} else {
+			entityDtos = append(entityDtos, dcDto)
+		}
+	}
 	// 4. Discovery Response
 	discoveryResponse := &proto.DiscoveryResponse{
 		EntityDTO: entityDtos,
+		ErrorDTO:  errorDtos,
+	}
+	return discoveryResponse
+}
+
+// Error for an agent that could not be discovered completely.
+// Agent errors are warnings so the entities for the other agents are accepted by the server.
+func createAgentErrorDTO(agent *data.Agent, err error) *proto.ErrorDTO {
+	severity := proto.ErrorDTO_WARNING
+	description := fmt.Sprintf("Agent %s::%s : %s", agent.Id, agent.IP, err)
+	entityType := proto.EntityDTO_VIRTUAL_MACHINE.String()
+	return &proto.ErrorDTO{
+		Severity:    &severity,
+		Description: &description,
+		EntityUuid:  &agent.Id,
+		EntityType:  &entityType,
 	}
-	return discoveryResponse, ec
 }
 
 func getSlaveIP(s data.Agent) (string, string) {
@@ -0,0 +1,118 @@
+package discovery
+
+import (
+	"fmt"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"strings"
+	"testing"
+)
+
+func TestCreateDiscoveryResponseErrors(t *testing.T) {
+	agents := createAgents(5)
+	entityId := "vm-0"
+	agentErrors := &ErrorCollector{fmt.Errorf("metric error")}
+	timeoutErrors := &ErrorCollector{fmt.Errorf("Agent discovery timed out")}
+	workerResponses := []DiscoveryWorkerResponse{
+		{
+			{agent: agents[0], entityDTOs: []*proto.EntityDTO{{Id: &entityId}}},
+			{agent: agents[1], entityDTOs: []*proto.EntityDTO{{Id: &entityId}}, errors: agentErrors},
+		},
+		{
+			{agent: agents[2]},
+			{agent: agents[3], errors: timeoutErrors, timedOut: true},
+			nil,
+		},
+	}
+	// agent-4 has no response
+	client := &MesosDiscoveryClient{agentList: agents, targetConf: &conf.MesosTargetConf{}}
+	discoveryResponse := client.createDiscoveryResponse(workerResponses)
+
+	if len(discoveryResponse.EntityDTO) != 2 {
+		t.Errorf("Expected 2 entities, got %d", len(discoveryResponse.EntityDTO))
+	}
+	expectedErrors := map[string]string{
+		"agent-1": "metric error",
+		"agent-2": "Null DTOs",
+		"agent-3": "Agent discovery timed out",
+		"agent-4": "Agent not discovered before the deadline",
+	}
+	if len(discoveryResponse.ErrorDTO) != len(expectedErrors) {
+		t.Fatalf("Expected %d errors, got %d", len(expectedErrors), len(discoveryResponse.ErrorDTO))
+	}
+	for _, errorDTO := range discoveryResponse.ErrorDTO {
+		agentId := errorDTO.GetEntityUuid()
+		expected, exists := expectedErrors[agentId]
+		if !exists {
+			t.Errorf("Unexpected error for %s : %s", agentId, errorDTO.GetDescription())
+			continue
+		}
+		if !strings.Contains(errorDTO.GetDescription(), expected) {
+			t.Errorf("Expected error '%s' for %s, got '%s'", expected, agentId, errorDTO.GetDescription())
+		}
+		if errorDTO.GetSeverity() != proto.ErrorDTO_WARNING {
+			t.Errorf("Expected warning for %s, got %s", agentId, errorDTO.GetSeverity())
+		}
+		if errorDTO.GetEntityType() != proto.EntityDTO_VIRTUAL_MACHINE.String() {
+			t.Errorf("Expected VM entity type for %s, got %s", agentId, errorDTO.GetEntityType())
+		}
+	}
+}
+
+func TestParseUnreachableAgents(t *testing.T) {
+	client := &MesosDiscoveryClient{
+		targetConf: &conf.MesosTargetConf{MasterIPPort: "10.0.0.1:5050"},
+		mesosMaster: &data.MesosMaster{
+			AgentMap: map[string]*data.Agent{
+				"agent-1": {Id: "agent-1", Pid: "slave(1)@10.0.0.11:5051", Hostname: "host-1"},
+				"agent-4": {Id: "agent-4", Pid: "slave(1)@10.0.0.14:5051", Hostname: "host-4"},
+			},
+		},
+	}
+	stateResp := &data.MesosAPIResponse{
+		Agents:            []data.Agent{},
+		UnreachableAgents: 2,
+		Frameworks: []data.Framework{
+			{
+				Id: "framework-1",
+				UnreachableTasks: []data.Task{
+					{Id: "task-1", SlaveId: "agent-1"},
+					{Id: "task-2", SlaveId: "agent-2"},
+				},
+			},
+		},
+	}
+	recoveredAgents := []data.Agent{
+		{Id: "agent-3", Hostname: "10.0.0.13", Port: 5051},
+		{Id: "agent-4", Hostname: "host-4", Port: 5051},
+	}
+	mesosMaster, err := client.parseMesosState(stateResp, nil, recoveredAgents)
+	if err != nil {
+		t.Fatalf("Error parsing state : %s", err)
+	}
+
+	// agent-2 was not seen before and has no address
+	if _, exists := mesosMaster.AgentMap["agent-2"]; exists {
+		t.Errorf("Expected unresolved agent-2 to be skipped")
+	}
+	if len(client.agentList) != 3 {
+		t.Errorf("Expected 3 agents, got %d", len(client.agentList))
+	}
+	for agentId, expectedIP := range map[string]string{"agent-1": "10.0.0.11", "agent-3": "10.0.0.13", "agent-4": "10.0.0.14"} {
+		agent, exists := mesosMaster.AgentMap[agentId]
+		if !exists {
+			t.Errorf("Expected unreachable agent %s", agentId)
+			continue
+		}
+		if agent.IP != expectedIP || agent.State != data.AGENT_UNREACHABLE {
+			t.Errorf("Unexpected agent %s : ip %s state %s", agentId, agent.IP, agent.State)
+		}
+	}
+	if agent := mesosMaster.AgentMap["agent-1"]; agent.Hostname != "host-1" {
+		t.Errorf("Expected agent-1 hostname from the previous discovery, got %s", agent.Hostname)
+	}
+	if task := mesosMaster.TaskMap["task-1"]; task == nil || task.State != TASK_UNREACHABLE {
+		t.Errorf("Expected unreachable task-1 on agent-1, got %+v", task)
+	}
+}
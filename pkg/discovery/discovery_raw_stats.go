@@ -1,9 +1,13 @@
 package discovery
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/turbonomic/mesosturbo/pkg/data"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"time"
 )
 
@@ -14,6 +18,8 @@ type RawStatsCache struct {
 
 type NodeStatistics struct {
 	nodeId string
+	// time when the statistics of the node were collected
+	discoveryTime *time.Time
 	//raw statistics from the rest api for each task
 	taskStats map[string]*TaskStatistics
 }
@@ -69,23 +75,175 @@ func CreateCopy(origCache *RawStatsCache, nodeIdList []string) *RawStatsCache {
 }
 
 func (rawStatsCache *RawStatsCache) RefreshCache(mesosMaster *data.MesosMaster) {
+	var agentList []*data.Agent
+	for _, agent := range mesosMaster.AgentMap {
+		agentList = append(agentList, agent)
+	}
+	rawStatsCache.RefreshAgentStats(agentList)
+}
+
+// Update the cache with the raw statistics of the tasks on the given agents.
+// The statistics of other agents are kept, so agents that missed the discovery deadline
+// use the statistics from their last completed discovery in the next cycle.
+func (rawStatsCache *RawStatsCache) RefreshAgentStats(agentList []*data.Agent) {
 	// Save discovery stats
 	currentTime := time.Now()
 	rawStatsCache.lastDiscoveryTime = &currentTime
-	// make a new map for all nodes to clear previous entries
-	rawStatsCache.nodeStats = make(map[string]*NodeStatistics)
-	for _, agent := range mesosMaster.AgentMap {
-		rawStatsCache.nodeStats[agent.Id] = &NodeStatistics{
-			nodeId:    agent.Id,
-			taskStats: make(map[string]*TaskStatistics),
+	if rawStatsCache.nodeStats == nil {
+		rawStatsCache.nodeStats = make(map[string]*NodeStatistics)
+	}
+	for _, agent := range agentList {
+		nodeStats := &NodeStatistics{
+			nodeId:        agent.Id,
+			discoveryTime: &currentTime,
+			taskStats:     make(map[string]*TaskStatistics),
 		}
-		taskMap := agent.TaskMap
-		nodeStats := rawStatsCache.nodeStats[agent.Id]
-		for _, task := range taskMap {
+		for _, task := range agent.TaskMap {
 			nodeStats.taskStats[task.Id] = &TaskStatistics{
 				taskId:   task.Id,
 				rawStats: task.RawStatistics,
 			}
 		}
+		// Task group executors are saved using the pod id
+		for _, pod := range agent.PodMap {
+			nodeStats.taskStats[pod.Id] = &TaskStatistics{
+				taskId:   pod.Id,
+				rawStats: pod.RawStatistics,
+			}
+		}
+		// Executors not matched to a task or a pod are saved using the executor key
+		for executorKey, rawStats := range agent.ExecutorStats {
+			nodeStats.taskStats[executorKey] = &TaskStatistics{
+				taskId:   executorKey,
+				rawStats: rawStats,
+			}
+		}
+		rawStatsCache.nodeStats[agent.Id] = nodeStats
+	}
+}
+
+// Remove the statistics of the agents that are not in the given list
+func (rawStatsCache *RawStatsCache) RetainAgents(agentList []*data.Agent) {
+	agentIds := make(map[string]bool)
+	for _, agent := range agentList {
+		agentIds[agent.Id] = true
+	}
+	for nodeId := range rawStatsCache.nodeStats {
+		if !agentIds[nodeId] {
+			delete(rawStatsCache.nodeStats, nodeId)
+		}
+	}
+}
+
+// Time when the statistics of the node were collected, nil if the node has no statistics
+func (rawStatsCache *RawStatsCache) getNodeDiscoveryTime(nodeId string) *time.Time {
+	nodeStats, exists := rawStatsCache.nodeStats[nodeId]
+	if !exists {
+		return nil
+	}
+	if nodeStats.discoveryTime != nil {
+		return nodeStats.discoveryTime
+	}
+	return rawStatsCache.lastDiscoveryTime
+}
+
+// =============================================== Stats Cache Snapshot ======================================
+// Snapshot of the raw stats cache saved on disk, so the usage can be computed in the first discovery after a restart
+type RawStatsSnapshot struct {
+	// Target for which the stats were collected
+	TargetId          string    `json:"target-id"`
+	LastDiscoveryTime time.Time `json:"last-discovery-time"`
+	// Raw statistics for each agent and task
+	NodeStats map[string]map[string]data.Statistics `json:"node-stats"`
+	// Time when the statistics of each agent were collected
+	NodeDiscoveryTimes map[string]time.Time `json:"node-discovery-times,omitempty"`
+}
+
+// Save the snapshot of the cache to the given file.
+// The snapshot is written to a temporary file first and renamed, so a partially written snapshot is never loaded.
+func (rawStatsCache *RawStatsCache) Save(targetId, path string) error {
+	if rawStatsCache.lastDiscoveryTime == nil {
+		return fmt.Errorf("no stats to save")
+	}
+	snapshot := &RawStatsSnapshot{
+		TargetId:           targetId,
+		LastDiscoveryTime:  *rawStatsCache.lastDiscoveryTime,
+		NodeStats:          make(map[string]map[string]data.Statistics),
+		NodeDiscoveryTimes: make(map[string]time.Time),
+	}
+	for nodeId, nodeStats := range rawStatsCache.nodeStats {
+		if nodeStats.discoveryTime != nil {
+			snapshot.NodeDiscoveryTimes[nodeId] = *nodeStats.discoveryTime
+		}
+		taskStatsMap := make(map[string]data.Statistics)
+		for taskId, taskStats := range nodeStats.taskStats {
+			taskStatsMap[taskId] = taskStats.rawStats
+		}
+		snapshot.NodeStats[nodeId] = taskStatsMap
+	}
+
+	byteContent, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("error in json marshal of stats snapshot : %s", err)
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("error creating stats snapshot file : %s", err)
+	}
+	_, err = tmpFile.Write(byteContent)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("error writing stats snapshot file : %s", err)
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("error saving stats snapshot file : %s", err)
+	}
+	return nil
+}
+
+// Load the cache from the snapshot in the given file.
+// Returns an error if the snapshot is for a different target or older than the given max age.
+func LoadRawStatsCache(targetId, path string, maxAge time.Duration) (*RawStatsCache, error) {
+	byteContent, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stats snapshot file : %s", err)
+	}
+	var snapshot RawStatsSnapshot
+	if err := json.Unmarshal(byteContent, &snapshot); err != nil {
+		return nil, fmt.Errorf("error in json unmarshal of stats snapshot : %s", err)
+	}
+	if snapshot.TargetId != targetId {
+		return nil, fmt.Errorf("stats snapshot is for target %s", snapshot.TargetId)
+	}
+	age := time.Since(snapshot.LastDiscoveryTime)
+	if age < 0 || age > maxAge {
+		return nil, fmt.Errorf("stats snapshot from %s is stale", snapshot.LastDiscoveryTime)
+	}
+
+	lastDiscoveryTime := snapshot.LastDiscoveryTime
+	rawStatsCache := &RawStatsCache{
+		lastDiscoveryTime: &lastDiscoveryTime,
+		nodeStats:         make(map[string]*NodeStatistics),
+	}
+	for nodeId, taskStatsMap := range snapshot.NodeStats {
+		nodeStats := &NodeStatistics{
+			nodeId:    nodeId,
+			taskStats: make(map[string]*TaskStatistics),
+		}
+		if discoveryTime, exists := snapshot.NodeDiscoveryTimes[nodeId]; exists {
+			nodeStats.discoveryTime = &discoveryTime
+		}
+		for taskId, rawStats := range taskStatsMap {
+			nodeStats.taskStats[taskId] = &TaskStatistics{
+				taskId:   taskId,
+				rawStats: rawStats,
+			}
+		}
+		rawStatsCache.nodeStats[nodeId] = nodeStats
 	}
+	return rawStatsCache, nil
 }
@@ -0,0 +1,106 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRawStatsCacheSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stats-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "stats.json")
+
+	task := &data.Task{Id: "task-1", RawStatistics: data.Statistics{CPUuserTimeSecs: 12.5, MemRSSBytes: 1024}}
+	mesosMaster := &data.MesosMaster{
+		AgentMap: map[string]*data.Agent{
+			"agent-1": {Id: "agent-1", TaskMap: map[string]*data.Task{task.Id: task}},
+		},
+	}
+	cache := &RawStatsCache{}
+	cache.RefreshCache(mesosMaster)
+	if err := cache.Save("10.10.10.10:5050", path); err != nil {
+		t.Fatalf("Error saving snapshot : %s", err)
+	}
+
+	loaded, err := LoadRawStatsCache("10.10.10.10:5050", path, time.Minute)
+	if err != nil {
+		t.Fatalf("Error loading snapshot : %s", err)
+	}
+	if !loaded.lastDiscoveryTime.Equal(*cache.lastDiscoveryTime) {
+		t.Errorf("Expected discovery time %v, got %v", cache.lastDiscoveryTime, loaded.lastDiscoveryTime)
+	}
+	taskStats := loaded.GetTaskStats("agent-1", "task-1")
+	if taskStats == nil || taskStats.rawStats != task.RawStatistics {
+		t.Errorf("Expected task stats %+v, got %+v", task.RawStatistics, taskStats)
+	}
+
+	if _, err := LoadRawStatsCache("10.10.10.11:5050", path, time.Minute); err == nil {
+		t.Errorf("Expected error for snapshot of another target")
+	}
+	if _, err := LoadRawStatsCache("10.10.10.10:5050", path, 0); err == nil {
+		t.Errorf("Expected error for stale snapshot")
+	}
+}
+
+func TestRawStatsCacheTimedOutAgent(t *testing.T) {
+	newAgent := func(id string, cpuSecs float64) *data.Agent {
+		task := &data.Task{Id: id + "-task", RawStatistics: data.Statistics{CPUuserTimeSecs: cpuSecs}}
+		return &data.Agent{Id: id, CPUMHz: 1000, TaskMap: map[string]*data.Task{task.Id: task}}
+	}
+
+	// first cycle, both agents are discovered
+	cache := &RawStatsCache{}
+	cache.RefreshAgentStats([]*data.Agent{newAgent("agent-1", 100), newAgent("agent-2", 100)})
+	firstTime := time.Now().Add(-20 * time.Second)
+	for _, nodeStats := range cache.nodeStats {
+		nodeStats.discoveryTime = &firstTime
+	}
+
+	// second cycle, agent-2 misses the deadline and keeps the stats from the first cycle
+	allAgents := []*data.Agent{newAgent("agent-1", 105), newAgent("agent-2", 200)}
+	cache.RefreshAgentStats(allAgents[:1])
+	cache.RetainAgents(allAgents)
+	if taskStats := cache.GetTaskStats("agent-1", "agent-1-task"); taskStats == nil || taskStats.rawStats.CPUuserTimeSecs != 105 {
+		t.Errorf("Expected refreshed stats for agent-1, got %+v", taskStats)
+	}
+	if taskStats := cache.GetTaskStats("agent-2", "agent-2-task"); taskStats == nil || taskStats.rawStats.CPUuserTimeSecs != 100 {
+		t.Errorf("Expected first cycle stats for agent-2, got %+v", taskStats)
+	}
+	if discoveryTime := cache.getNodeDiscoveryTime("agent-2"); discoveryTime == nil || !discoveryTime.Equal(firstTime) {
+		t.Errorf("Expected first cycle discovery time for agent-2, got %v", discoveryTime)
+	}
+
+	// third cycle, the usage of agent-2 is computed over the time since its last completed discovery
+	agent := newAgent("agent-2", 110)
+	executors := []data.Executor{{Source: "agent-2-task", Statistics: agent.TaskMap["agent-2-task"].RawStatistics}}
+	monitor := &DefaultMesosMonitor{}
+	if err := monitor.parseAgentUsedStats(agent, executors, nil, CreateCopy(cache, []string{"agent-2"}), nil); err != nil {
+		t.Fatalf("Error parsing stats : %s", err)
+	}
+	if used := agent.ResourceUseStats.CPUMHz; used < 450 || used > 500 {
+		t.Errorf("Expected about 500 MHz used for agent-2, got %f", used)
+	}
+
+	// a task without previous stats has no cpu usage
+	agent = newAgent("agent-3", 110)
+	executors = []data.Executor{{Source: "agent-3-task", Statistics: agent.TaskMap["agent-3-task"].RawStatistics}}
+	if err := monitor.parseAgentUsedStats(agent, executors, nil, CreateCopy(cache, []string{"agent-3"}), nil); err != nil {
+		t.Fatalf("Error parsing stats : %s", err)
+	}
+	if used := agent.ResourceUseStats.CPUMHz; used != 0 {
+		t.Errorf("Expected no cpu usage without previous stats, got %f", used)
+	}
+
+	// agents removed from the cluster are dropped
+	cache.RetainAgents(allAgents[:1])
+	if _, exists := cache.nodeStats["agent-2"]; exists {
+		t.Errorf("Expected agent-2 stats to be removed")
+	}
+}
@@ -1,19 +1,20 @@
 package discovery
 
 import (
+	"context"
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/turbonomic/mesosturbo/pkg/conf"
 	"github.com/turbonomic/mesosturbo/pkg/data"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
-	"sync"
+	"sort"
 	"time"
 )
 
 // =============================================== Discovery and local Monitoring Tasks ======================================
 // Worker Task to run discovery and monitoring on an agent node
 type AgentTask interface {
-	ProcessAgent() *AgentTaskResponse
+	ProcessAgent(ctx context.Context) *AgentTaskResponse
 }
 
 type AgentTaskResponse struct {
@@ -21,6 +22,10 @@ type AgentTaskResponse struct {
 	nodeRepository *NodeRepository
 	entityDTOs     []*proto.EntityDTO
 	errors         *ErrorCollector
+	// Set if the agent was not processed before the deadline
+	timedOut bool
+	// Time taken to process the agent
+	elapsed time.Duration
 }
 
 // Implementation for a Mesos Agent
@@ -29,9 +34,14 @@ type MesosAgentTask struct {
 	masterConf    *conf.MasterConf
 	metricsStore  *MesosMetricsMetadataStore
 	rawStatsCache *RawStatsCache
+	sampler       *StatsSampler
+	monitorGroup  *MonitorGroup
+	stitchingConf *conf.StitchingConf
 }
 
-func (agentTask MesosAgentTask) ProcessAgent() *AgentTaskResponse {
+// Discover and monitor the agent until the given context is done.
+// The entities are not created if the agent is not processed before the deadline.
+func (agentTask MesosAgentTask) ProcessAgent(ctx context.Context) *AgentTaskResponse {
 	ec := new(ErrorCollector)
 	// Discovery related
 	// Create Repository with entity objects for node, tasks and containers based on the Mesos data structures
@@ -45,6 +55,10 @@ func (agentTask MesosAgentTask) ProcessAgent() *AgentTaskResponse {
 		containerEntity := nodeRepository.CreateContainerEntity(task.Id)
 		containerEntity.task = task // save in the entity
 	}
+	for _, pod := range node.PodMap {
+		podEntity := nodeRepository.CreatePodEntity(pod.Id)
+		podEntity.pod = pod
+	}
 
 	// Monitoring related
 	// Get metrics for each entity gathered locally from the agent
@@ -57,14 +71,25 @@ func (agentTask MesosAgentTask) ProcessAgent() *AgentTaskResponse {
 		config:          agentTask.masterConf,
 		repository:      nodeRepository,
 		rawStatsCache:   agentTask.rawStatsCache,
+		sampler:         agentTask.sampler,
 		monitoringProps: monitoringPropsMap,
 	}
 
-	defaultMonitor := &DefaultMesosMonitor{}
-	errors := defaultMonitor.Monitor(monitorTarget)
-	ec.Collect(errors)
-	if ec.Count() > 1 {
-		glog.Errorf("%s : Monitor errors %s\n", node.IP, errors)
+	monitorErrors := agentTask.monitorGroup.Monitor(ctx, monitorTarget)
+	for monitorName, errors := range monitorErrors {
+		ec.Collect(fmt.Errorf("%s : %s", monitorName, errors))
+		glog.Errorf("%s : %s monitor errors %s\n", node.IP, monitorName, errors)
+	}
+
+	if ctx.Err() != nil {
+		ec.Collect(fmt.Errorf("%s : Agent discovery timed out : %s", node.IP, ctx.Err()))
+		glog.Errorf("%s : Agent discovery timed out", node.IP)
+		return &AgentTaskResponse{
+			nodeRepository: nodeRepository,
+			errors:         ec,
+			agent:          agentTask.node,
+			timedOut:       true,
+		}
 	}
 
 	//PrintRepository(nodeRepository)
@@ -96,25 +121,45 @@ func (agentTask MesosAgentTask) createDiscoveryDTOs(nodeRepository *NodeReposito
 	var nodeBuilder EntityBuilder
 	nodeBuilder = &VMEntityBuilder{
 		nodeRepository: nodeRepository,
+		stitchingConf:  agentTask.stitchingConf,
 	}
 	nodeEntityDtos, err := nodeBuilder.BuildEntities()
-	errList = append(errList, fmt.Errorf("Error parsing nodes: %s", err))
+	if err != nil {
+		errList = append(errList, fmt.Errorf("Error parsing nodes: %s", err))
+	}
 	entityDtos = append(entityDtos, nodeEntityDtos...)
 
+	var podBuilder EntityBuilder
+	podBuilder = &PodEntityBuilder{
+		nodeRepository: nodeRepository,
+		stitchingConf:  agentTask.stitchingConf,
+	}
+	podEntityDtos, err := podBuilder.BuildEntities()
+	if err != nil {
+		errList = append(errList, fmt.Errorf("Error parsing pods: %s", err))
+	}
+	entityDtos = append(entityDtos, podEntityDtos...)
+
 	var containerBuilder EntityBuilder
 	containerBuilder = &ContainerEntityBuilder{
 		nodeRepository: nodeRepository,
+		stitchingConf:  agentTask.stitchingConf,
 	}
 	containerEntityDtos, err := containerBuilder.BuildEntities()
-	errList = append(errList, fmt.Errorf("Error parsing containers: %s", err))
+	if err != nil {
+		errList = append(errList, fmt.Errorf("Error parsing containers: %s", err))
+	}
 	entityDtos = append(entityDtos, containerEntityDtos...)
 
 	var appBuilder EntityBuilder
 	appBuilder = &AppEntityBuilder{
 		nodeRepository: nodeRepository,
+		stitchingConf:  agentTask.stitchingConf,
 	}
 	appEntityDtos, err := appBuilder.BuildEntities()
-	errList = append(errList, fmt.Errorf("Error parsing tasks: %s", err))
+	if err != nil {
+		errList = append(errList, fmt.Errorf("Error parsing tasks: %s", err))
+	}
 	entityDtos = append(entityDtos, appEntityDtos...)
 
 	return entityDtos, errList
@@ -128,24 +173,35 @@ type DiscoveryWorker struct {
 	// complete mesos master state and config
 	masterConf *conf.MasterConf
 	// subset of a agent map from the mesos master state
-	nodeList          []*data.Agent
-	nodeResponseQueue chan *AgentTaskResponse //TODO: change to dto queue
+	nodeList []*data.Agent
 	// metrics collection related
 	metricsStore  *MesosMetricsMetadataStore
 	rawStatsCache *RawStatsCache
+	sampler       *StatsSampler
+	monitorGroup  *MonitorGroup
+	// Deadline for processing each agent
+	agentTimeout time.Duration
+	// Pool bounding the concurrent agent tasks
+	pool *AgentTaskPool
+	// Stitching with the entities discovered by the other targets, nil for the default stitching
+	stitchingConf *conf.StitchingConf
 }
 
 // Discovery worker for set of nodes grouped by certain criterion to distribute discovery
-func NewDiscoveryWorker(masterConf *conf.MasterConf, nodeList []*data.Agent, rawStatsCache *RawStatsCache) *DiscoveryWorker {
+func NewDiscoveryWorker(masterConf *conf.MasterConf, nodeList []*data.Agent, rawStatsCache *RawStatsCache, sampler *StatsSampler, monitorGroup *MonitorGroup, agentTimeout time.Duration, pool *AgentTaskPool, stitchingConf *conf.StitchingConf) *DiscoveryWorker {
 	if nodeList == nil || len(nodeList) == 0 {
 		glog.Errorf("No agents specified for discovery worker")
 		return nil
 	}
 	worker := &DiscoveryWorker{
-		masterConf:        masterConf,
-		nodeList:          nodeList,
-		nodeResponseQueue: make(chan *AgentTaskResponse, 1),
-		rawStatsCache:     rawStatsCache,
+		masterConf:    masterConf,
+		nodeList:      nodeList,
+		rawStatsCache: rawStatsCache,
+		sampler:       sampler,
+		monitorGroup:  monitorGroup,
+		agentTimeout:  agentTimeout,
+		pool:          pool,
+		stitchingConf: stitchingConf,
 	}
 
 	// Create metrics collector for this worker here and pass it to the different agent tasks
@@ -157,47 +213,76 @@ func (worker *DiscoveryWorker) SetName(name string) {
 	worker.name = name
 }
 
-func (worker *DiscoveryWorker) DoWork() DiscoveryWorkerResponse {
+// Process the agents of the worker from a work queue using the number of routines configured in the pool,
+// each agent with its own deadline.
+// Returns the responses for the agents processed before the given context is done.
+func (worker *DiscoveryWorker) DoWork(ctx context.Context) DiscoveryWorkerResponse {
 	// Get discovered Entities
 	// Get Metrics
 	// Put in repository
 	var agentTaskResponseList []*AgentTaskResponse
-	wg := new(sync.WaitGroup)
-
-	//workerRepos := make([]*NodeRepository, len(worker.nodeList))
+	// buffered so the agent tasks that finish after the deadline do not block
+	nodeResponseQueue := make(chan *AgentTaskResponse, len(worker.nodeList))
 
+	agentQueue := make(chan *QueuedAgentTask, len(worker.nodeList))
 	for idx, _ := range worker.nodeList {
-		wg.Add(1)
-		go func(idx int) {
-			node := worker.nodeList[idx]
-			//fmt.Printf("%s : Begin Process Agent %d::%s\n", worker.name, idx, node.IP)
-			glog.V(3).Infof("%s: Begin Process Agent %d::%s", worker.name, idx, node.Id)
-			agentTask := &MesosAgentTask{
-				node:          node,
+		agentQueue <- &QueuedAgentTask{
+			idx: idx,
+			agentTask: &MesosAgentTask{
+				node:          worker.nodeList[idx],
 				masterConf:    worker.masterConf,
 				metricsStore:  worker.metricsStore,
 				rawStatsCache: worker.rawStatsCache,
+				sampler:       worker.sampler,
+				monitorGroup:  worker.monitorGroup,
+				stitchingConf: worker.stitchingConf,
+			},
+			queuedTime: time.Now(),
+		}
+	}
+	close(agentQueue)
+
+	numRoutines := worker.pool.WorkerConcurrency(len(worker.nodeList))
+	glog.V(3).Infof("%s : Processing %d agents using %d routines", worker.name, len(worker.nodeList), numRoutines)
+	for i := 0; i < numRoutines; i++ {
+		go func() {
+			for queuedTask := range agentQueue {
+				if !worker.pool.Acquire(ctx) {
+					return // deadline exceeded, the remaining agents are not processed
+				}
+				worker.pool.GetMetrics().Record(time.Since(queuedTask.queuedTime))
+				nodeResponse := worker.processAgent(ctx, queuedTask)
+				worker.pool.Release()
+				nodeResponseQueue <- nodeResponse //send it on the channel/queue
 			}
-			nodeResponse := agentTask.ProcessAgent() //TODO: <-- returns node repository
-
-			//workerRepos[idx] = nodeResponse
-			worker.nodeResponseQueue <- nodeResponse //send it on the channel/queue
-			//fmt.Printf("%s : End Process Agent %d::%s, num of tasks: %d\n",  worker.name, idx, node.IP, len(nodeResponse.nodeRepository.taskEntities))
-			glog.V(3).Infof("%s : End Process Agent %d::%s, num of tasks: %d", worker.name, idx, node.Id, len(nodeResponse.nodeRepository.taskEntities))
-		}(idx)
+		}()
 	}
-	go func() {
-		for nodeRepos := range worker.nodeResponseQueue {
-			agentTaskResponseList = append(agentTaskResponseList, nodeRepos)
-			wg.Done() // ** move the `Done()` call here
-		}
-	}()
 
-	wg.Wait()
-	//glog.V(3).Infof("%s : Done all agent tasks\n", worker.name)
+	for len(agentTaskResponseList) < len(worker.nodeList) {
+		select {
+		case nodeResponse := <-nodeResponseQueue:
+			agentTaskResponseList = append(agentTaskResponseList, nodeResponse)
+		case <-ctx.Done():
+			glog.Errorf("%s : Discovery deadline exceeded, completed %d of %d agents",
+				worker.name, len(agentTaskResponseList), len(worker.nodeList))
+			return DiscoveryWorkerResponse(agentTaskResponseList)
+		}
+	}
 	return DiscoveryWorkerResponse(agentTaskResponseList)
 }
 
+func (worker *DiscoveryWorker) processAgent(ctx context.Context, queuedTask *QueuedAgentTask) *AgentTaskResponse {
+	node := queuedTask.agentTask.node
+	glog.V(3).Infof("%s: Begin Process Agent %d::%s", worker.name, queuedTask.idx, node.Id)
+	agentCtx, cancel := context.WithTimeout(ctx, worker.agentTimeout)
+	defer cancel()
+	startTime := time.Now()
+	nodeResponse := queuedTask.agentTask.ProcessAgent(agentCtx)
+	nodeResponse.elapsed = time.Since(startTime)
+	glog.V(3).Infof("%s : End Process Agent %d::%s, num of tasks: %d", worker.name, queuedTask.idx, node.Id, len(nodeResponse.nodeRepository.taskEntities))
+	return nodeResponse
+}
+
 // ==============================================
 type StopWatch struct {
 	name      string
@@ -271,30 +356,65 @@ type FixedWorkerSizeSelector struct {
 func NewFixedWorkerSizeSelector(groupSize int) *FixedWorkerSizeSelector {
 	return &FixedWorkerSizeSelector{groupSize: groupSize}
 }
+// Split the agents into the fixed number of groups, the group sizes differ by at most one agent
 func (selector *FixedWorkerSizeSelector) GetAgents(agentList []*data.Agent) [][]*data.Agent {
+	return splitAgents(agentList, selector.groupSize)
+}
 
-	numOfAgentsPerGroup := len(agentList)
-	if numOfAgentsPerGroup > selector.groupSize {
-		numOfAgentsPerGroup = len(agentList) / selector.groupSize
+func splitAgents(agentList []*data.Agent, numGroups int) [][]*data.Agent {
+	if numGroups > len(agentList) {
+		numGroups = len(agentList)
 	}
 	var agentGroup [][]*data.Agent
-	var newAgentList []*data.Agent
-	newAgentList = []*data.Agent{}
-	agentCounter := 0
-	for i, _ := range agentList {
-		agent := agentList[i]
-		agentCounter++
-		newAgentList = append(newAgentList, agent)
-		if agentCounter == numOfAgentsPerGroup {
-			agentGroup = append(agentGroup, newAgentList)
-			newAgentList = []*data.Agent{}
-			agentCounter = 0
+	start := 0
+	for i := 0; i < numGroups; i++ {
+		// the first groups get one of the leftover agents each
+		size := len(agentList) / numGroups
+		if i < len(agentList)%numGroups {
+			size++
 		}
-	}
-	if len(newAgentList) > 0 {
-		agentList := agentGroup[0]
-		agentList = append(agentList, newAgentList...)
-		glog.Info("[FixedWorkerSizeSelector] nlast AgentList: %s", agentList)
+		agentGroup = append(agentGroup, agentList[start:start+size])
+		start += size
 	}
 	return agentGroup
 }
+
+// Groups the agents into a fixed number of groups by the time taken to discover each agent in the previous cycle,
+// so the slow agents are processed together and do not delay the fast ones.
+// Agents without a previous latency are assumed to have the average latency.
+type LatencyAgentSelector struct {
+	groupSize    int
+	agentLatency map[string]time.Duration
+}
+
+func NewLatencyAgentSelector(groupSize int, agentLatency map[string]time.Duration) *LatencyAgentSelector {
+	return &LatencyAgentSelector{groupSize: groupSize, agentLatency: agentLatency}
+}
+
+func (selector *LatencyAgentSelector) GetAgents(agentList []*data.Agent) [][]*data.Agent {
+	var totalLatency time.Duration
+	var numKnown int
+	for _, agent := range agentList {
+		if latency, exists := selector.agentLatency[agent.Id]; exists {
+			totalLatency += latency
+			numKnown++
+		}
+	}
+	var avgLatency time.Duration
+	if numKnown > 0 {
+		avgLatency = totalLatency / time.Duration(numKnown)
+	}
+	latencyOf := func(agent *data.Agent) time.Duration {
+		if latency, exists := selector.agentLatency[agent.Id]; exists {
+			return latency
+		}
+		return avgLatency
+	}
+
+	sortedAgents := make([]*data.Agent, len(agentList))
+	copy(sortedAgents, agentList)
+	sort.SliceStable(sortedAgents, func(i, j int) bool {
+		return latencyOf(sortedAgents[i]) < latencyOf(sortedAgents[j])
+	})
+	return splitAgents(sortedAgents, selector.groupSize)
+}
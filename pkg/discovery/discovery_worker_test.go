@@ -0,0 +1,63 @@
+package discovery
+
+import (
+	"fmt"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"testing"
+	"time"
+)
+
+func createAgents(numAgents int) []*data.Agent {
+	var agentList []*data.Agent
+	for i := 0; i < numAgents; i++ {
+		agentList = append(agentList, &data.Agent{Id: fmt.Sprintf("agent-%d", i)})
+	}
+	return agentList
+}
+
+func TestFixedWorkerSizeSelectorKeepsAllAgents(t *testing.T) {
+	agentList := createAgents(23)
+	agentGroups := NewFixedWorkerSizeSelector(10).GetAgents(agentList)
+	if len(agentGroups) != 10 {
+		t.Fatalf("Expected 10 groups, got %d", len(agentGroups))
+	}
+	seen := make(map[string]bool)
+	for _, group := range agentGroups {
+		if len(group) < 2 || len(group) > 3 {
+			t.Errorf("Unexpected group size %d", len(group))
+		}
+		for _, agent := range group {
+			seen[agent.Id] = true
+		}
+	}
+	if len(seen) != len(agentList) {
+		t.Errorf("Expected %d agents in the groups, got %d", len(agentList), len(seen))
+	}
+
+	agentGroups = NewFixedWorkerSizeSelector(10).GetAgents(createAgents(3))
+	if len(agentGroups) != 3 {
+		t.Errorf("Expected 3 groups, got %d", len(agentGroups))
+	}
+}
+
+func TestLatencyAgentSelector(t *testing.T) {
+	agentList := createAgents(4)
+	agentLatency := map[string]time.Duration{
+		"agent-0": 30 * time.Second,
+		"agent-1": time.Second,
+		"agent-2": 20 * time.Second,
+	}
+	// agent-3 has no latency and is grouped using the average latency of 17s
+	agentGroups := NewLatencyAgentSelector(2, agentLatency).GetAgents(agentList)
+	if len(agentGroups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(agentGroups))
+	}
+	expected := [][]string{{"agent-1", "agent-3"}, {"agent-2", "agent-0"}}
+	for i, group := range agentGroups {
+		for j, agent := range group {
+			if agent.Id != expected[i][j] {
+				t.Errorf("Group %d: expected %s at %d, got %s", i, expected[i][j], j, agent.Id)
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package discovery
 import (
 	"github.com/turbonomic/mesosturbo/pkg/data"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"strconv"
 )
 
 type EntityBuilder interface {
@@ -13,6 +14,11 @@ var (
 	DEFAULT_NAMESPACE string = "DEFAULT"
 )
 
+const (
+	METRICS_STATE_PROPERTY string = "metrics-state"
+	STALE_CYCLES_PROPERTY  string = "metrics-stale-cycles"
+)
+
 //TODO: change sdk builder to accept nil values
 // Returns 0 if value is nil or not set
 func getEntityMetricValue(mesosEntity MesosEntity, resourceType data.ResourceType, metricType data.MetricPropType, ec *ErrorCollector) *float64 {
@@ -29,3 +35,56 @@ func getEntityMetricValue(mesosEntity MesosEntity, resourceType data.ResourceTyp
 
 	return &zero_value
 }
+
+// Used value for the container or application of the task, tasks that are not running yet or any more
+// have no executor stats and their usage is 0 instead of a missing metric
+func getTaskUsedValue(mesosEntity MesosEntity, task *data.Task, resourceType data.ResourceType, ec *ErrorCollector) *float64 {
+	if lifecycle, _ := getTaskLifecycle(task); lifecycle != TASK_ACTIVE && !hasMetricValue(mesosEntity, resourceType, data.USED) {
+		var zero_value = data.DEFAULT_VAL
+		return &zero_value
+	}
+	return getEntityMetricValue(mesosEntity, resourceType, data.USED, ec)
+}
+
+// Entity property in the default namespace
+func newEntityProperty(name, value string) *proto.EntityDTO_EntityProperty {
+	return &proto.EntityDTO_EntityProperty{
+		Namespace: &DEFAULT_NAMESPACE,
+		Name:      &name,
+		Value:     &value,
+	}
+}
+
+// Properties flagging the entities of an agent that did not respond with the state of the metrics
+// and the number of discoveries since the agent last responded
+func metricsStateProperties(agentEntity *AgentEntity) []*proto.EntityDTO_EntityProperty {
+	var properties []*proto.EntityDTO_EntityProperty
+	if agentEntity == nil || agentEntity.metricsState == "" || agentEntity.metricsState == METRICS_CURRENT {
+		return properties
+	}
+	properties = append(properties,
+		newEntityProperty(METRICS_STATE_PROPERTY, string(agentEntity.metricsState)),
+		newEntityProperty(STALE_CYCLES_PROPERTY, strconv.Itoa(agentEntity.staleCycles)))
+	return properties
+}
+
+// Keys of the access commodities bought for the task from the agent for the role and fault domains of the task
+func taskAccessKeys(task *data.Task, agent *data.Agent) []string {
+	var keys []string
+	if task.Role != "" {
+		keys = append(keys, getRoleKey(task.Role))
+	}
+	return append(keys, taskDomainKeys(task, agent)...)
+}
+
+// Set the peak value on the commodity if the peak metric is available for the resource
+func setCommodityPeak(commodity *proto.CommodityDTO, mesosEntity MesosEntity, resourceType data.ResourceType) {
+	if commodity == nil {
+		return
+	}
+	resourceMetric, err := mesosEntity.GetResourceMetric(resourceType, data.PEAK)
+	if err != nil || resourceMetric.value == nil {
+		return
+	}
+	commodity.Peak = resourceMetric.value
+}
@@ -0,0 +1,88 @@
+package discovery
+
+import (
+	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"strconv"
+)
+
+const (
+	// Key prefix for the statistics of the executors that are not matched to a task or a pod
+	EXECUTOR_KEY_PREFIX string = "executor::"
+	// Properties on the agent VM with the usage of the executors that are not matched to a task or a pod
+	UNATTRIBUTED_CPU_PROPERTY string = "unattributed-cpu-mhz"
+	UNATTRIBUTED_MEM_PROPERTY string = "unattributed-mem-kb"
+)
+
+// Key for the executor statistics, executor ids are unique within a framework
+func getExecutorKey(executor *data.Executor) string {
+	return EXECUTOR_KEY_PREFIX + executor.FrameworkId + "/" + executor.Id
+}
+
+// Find the task using the sourceId or the framework and executor id from the agents stats query response.
+// The executor shared by the tasks of a task group or a custom executor is not matched to a task.
+func findTask(executor *data.Executor, taskMap map[string]*data.Task) *data.Task {
+	task, ok := taskMap[executor.Source]
+	if ok && task.PodId == "" {
+		return task
+	}
+	for _, task := range taskMap {
+		if task.ExecutorId == executor.Id && task.FrameworkId == executor.FrameworkId && task.PodId == "" {
+			return task
+		}
+	}
+	return nil
+}
+
+// Attribute a share of the executor usage to the tasks of the pod without their own statistics.
+// The usage is split in proportion to the resources allocated to each task, or evenly if the tasks
+// do not have allocated resources.
+func splitPodUse(pod *data.Pod) {
+	if pod.ResourceUseStats == nil {
+		return
+	}
+	var tasks []*data.Task
+	var totalCPU, totalMem float64
+	for _, task := range pod.TaskMap {
+		if task.ResourceUseStats != nil {
+			continue
+		}
+		tasks = append(tasks, task)
+		totalCPU += task.Resources.CPUUnits
+		totalMem += task.Resources.MemMB
+	}
+	for _, task := range tasks {
+		cpuShare := resourceShare(task.Resources.CPUUnits, totalCPU, len(tasks))
+		memShare := resourceShare(task.Resources.MemMB, totalMem, len(tasks))
+		podUse := pod.ResourceUseStats
+		task.ResourceUseStats = &data.CalculatedUse{
+			CPUMHz:     podUse.CPUMHz * cpuShare,
+			MemKB:      podUse.MemKB * memShare,
+			Sampled:    podUse.Sampled,
+			CPUPeakMHz: podUse.CPUPeakMHz * cpuShare,
+			MemPeakKB:  podUse.MemPeakKB * memShare,
+		}
+		glog.V(3).Infof("%s::%s : Task share of the executor usage [cpu %f] [mem %f] %+v",
+			pod.Id, task.Name, cpuShare, memShare, task.ResourceUseStats)
+	}
+}
+
+func resourceShare(value, total float64, count int) float64 {
+	if total <= 0 {
+		return 1 / float64(count)
+	}
+	return value / total
+}
+
+// Properties with the usage of the executors on the agent that are not matched to a task or a pod
+func unattributedProperties(agent *data.Agent) []*proto.EntityDTO_EntityProperty {
+	var properties []*proto.EntityDTO_EntityProperty
+	if agent.UnattributedUseStats == nil {
+		return properties
+	}
+	properties = append(properties,
+		newEntityProperty(UNATTRIBUTED_CPU_PROPERTY, strconv.FormatFloat(agent.UnattributedUseStats.CPUMHz, 'f', 2, 64)),
+		newEntityProperty(UNATTRIBUTED_MEM_PROPERTY, strconv.FormatFloat(agent.UnattributedUseStats.MemKB, 'f', 2, 64)))
+	return properties
+}
@@ -0,0 +1,89 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"testing"
+)
+
+func TestFindTaskForExecutor(t *testing.T) {
+	taskMap := map[string]*data.Task{
+		"t1": {Id: "t1", FrameworkId: "f1", ExecutorId: "e1"},
+		"t2": {Id: "t2", FrameworkId: "f2", ExecutorId: "e2", PodId: "e2"},
+	}
+	if task := findTask(&data.Executor{Id: "e1", FrameworkId: "f1"}, taskMap); task == nil || task.Id != "t1" {
+		t.Errorf("Executor should match task t1, got %v", task)
+	}
+	if task := findTask(&data.Executor{Id: "e1", FrameworkId: "f2"}, taskMap); task != nil {
+		t.Errorf("Executor of another framework should not match a task, got %v", task)
+	}
+	if task := findTask(&data.Executor{Id: "e2", FrameworkId: "f2", Source: "t2"}, taskMap); task != nil {
+		t.Errorf("Executor of a pod should not match a task, got %v", task)
+	}
+}
+
+func TestSplitPodUse(t *testing.T) {
+	nested := &data.CalculatedUse{CPUMHz: 10, MemKB: 10}
+	pod := &data.Pod{
+		Id: "e1",
+		TaskMap: map[string]*data.Task{
+			"t1": {Id: "t1", Resources: data.Resources{CPUUnits: 3, MemMB: 100}},
+			"t2": {Id: "t2", Resources: data.Resources{CPUUnits: 1, MemMB: 300}},
+			"t3": {Id: "t3", ResourceUseStats: nested},
+		},
+		ResourceUseStats: &data.CalculatedUse{CPUMHz: 400, MemKB: 800},
+	}
+	splitPodUse(pod)
+
+	t1Use, t2Use := pod.TaskMap["t1"].ResourceUseStats, pod.TaskMap["t2"].ResourceUseStats
+	if t1Use == nil || t1Use.CPUMHz != 300 || t1Use.MemKB != 200 {
+		t.Errorf("Unexpected usage for t1 %+v", t1Use)
+	}
+	if t2Use == nil || t2Use.CPUMHz != 100 || t2Use.MemKB != 600 {
+		t.Errorf("Unexpected usage for t2 %+v", t2Use)
+	}
+	if pod.TaskMap["t3"].ResourceUseStats != nested {
+		t.Errorf("Nested container usage should not be replaced")
+	}
+}
+
+func TestCustomExecutorsAcrossFrameworks(t *testing.T) {
+	// two Spark frameworks on the agent, each with executor "0" running two tasks
+	agent := &data.Agent{
+		Id:     "a1",
+		CPUMHz: 1000,
+		TaskMap: map[string]*data.Task{
+			"t1": {Id: "t1", FrameworkId: "spark-1", ExecutorId: "0"},
+			"t2": {Id: "t2", FrameworkId: "spark-1", ExecutorId: "0"},
+			"t3": {Id: "t3", FrameworkId: "spark-2", ExecutorId: "0"},
+			"t4": {Id: "t4", FrameworkId: "spark-2", ExecutorId: "0"},
+		},
+	}
+	setAgentPods(agent)
+	if len(agent.PodMap) != 2 {
+		t.Fatalf("Expected a pod for each framework, got %d", len(agent.PodMap))
+	}
+	if agent.TaskMap["t1"].PodId == agent.TaskMap["t3"].PodId {
+		t.Errorf("Tasks of different frameworks should not share the pod %s", agent.TaskMap["t1"].PodId)
+	}
+
+	executors := []data.Executor{
+		{Id: "0", FrameworkId: "spark-1", Statistics: data.Statistics{MemRSSBytes: 100 * 1024}},
+		{Id: "0", FrameworkId: "spark-2", Statistics: data.Statistics{MemRSSBytes: 300 * 1024}},
+	}
+	monitor := &DefaultMesosMonitor{}
+	if err := monitor.parseAgentUsedStats(agent, executors, nil, &RawStatsCache{}, nil); err != nil {
+		t.Fatalf("Error parsing stats : %s", err)
+	}
+	if agent.UnattributedUseStats != nil {
+		t.Errorf("Executors should be attributed to the pods, got unattributed usage %+v", agent.UnattributedUseStats)
+	}
+	for podId, expectedMemKB := range map[string]float64{"a1/spark-1/0": 100, "a1/spark-2/0": 300} {
+		pod := agent.PodMap[podId]
+		if pod == nil || pod.ResourceUseStats == nil || pod.ResourceUseStats.MemKB != expectedMemKB {
+			t.Errorf("Unexpected usage for pod %s : %+v", podId, pod)
+		}
+	}
+	if agent.ResourceUseStats.MemKB != 400 {
+		t.Errorf("Expected agent memory usage 400 KB, got %f", agent.ResourceUseStats.MemKB)
+	}
+}
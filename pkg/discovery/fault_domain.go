@@ -0,0 +1,99 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	// Key prefix for the access commodities representing the fault domains
+	REGION_KEY_PREFIX string = "region::"
+	ZONE_KEY_PREFIX   string = "zone::"
+
+	REGION_PROPERTY string = "region"
+	ZONE_PROPERTY   string = "zone"
+
+	// Marathon constraint fields for the fault domain of the agents
+	REGION_CONSTRAINT_FIELD string = "@region"
+	ZONE_CONSTRAINT_FIELD   string = "@zone"
+)
+
+// Agents without a fault domain are in the region of the master
+func setAgentRegions(masterDomain *data.Domain, agentList []*data.Agent) {
+	if masterDomain == nil || masterDomain.FaultDomain == nil {
+		return
+	}
+	for _, agent := range agentList {
+		if agent.Domain == nil || agent.Domain.FaultDomain == nil {
+			agent.Domain = &data.Domain{
+				FaultDomain: &data.FaultDomain{Region: masterDomain.FaultDomain.Region},
+			}
+		}
+	}
+}
+
+// Save the Marathon app in each of its tasks
+func setTaskApps(mesosMaster *data.MesosMaster, apps []data.App) {
+	for idx := range apps {
+		app := &apps[idx]
+		for _, appTask := range app.Tasks {
+			if task, exists := mesosMaster.TaskMap[appTask.Id]; exists {
+				task.App = app
+			}
+		}
+	}
+}
+
+// True if the app has a constraint on the given fault domain field
+func hasDomainConstraint(app *data.App, field string) bool {
+	if app == nil {
+		return false
+	}
+	for _, constraint := range app.Constraints {
+		if len(constraint) > 0 && constraint[0] == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Properties for the region and zone of the agent
+func domainProperties(agent *data.Agent) []*proto.EntityDTO_EntityProperty {
+	var properties []*proto.EntityDTO_EntityProperty
+	for _, domain := range []struct{ name, value string }{
+		{REGION_PROPERTY, agent.Region()},
+		{ZONE_PROPERTY, agent.Zone()},
+	} {
+		if domain.value == "" {
+			continue
+		}
+		properties = append(properties, newEntityProperty(domain.name, domain.value))
+	}
+	return properties
+}
+
+// Keys of the fault domain commodities sold by the agent
+func agentDomainKeys(agent *data.Agent) []string {
+	var keys []string
+	if region := agent.Region(); region != "" {
+		keys = append(keys, REGION_KEY_PREFIX+region)
+	}
+	if zone := agent.Zone(); zone != "" {
+		keys = append(keys, ZONE_KEY_PREFIX+zone)
+	}
+	return keys
+}
+
+// Keys of the fault domain commodities bought by the container of a task with fault domain constraints.
+// The container is kept in the region or zone of its current agent, so the moves do not break the
+// placement or the spread of the app tasks across the fault domains.
+func taskDomainKeys(task *data.Task, agent *data.Agent) []string {
+	var keys []string
+	if region := agent.Region(); region != "" && hasDomainConstraint(task.App, REGION_CONSTRAINT_FIELD) {
+		keys = append(keys, REGION_KEY_PREFIX+region)
+	}
+	if zone := agent.Zone(); zone != "" && hasDomainConstraint(task.App, ZONE_CONSTRAINT_FIELD) {
+		keys = append(keys, ZONE_KEY_PREFIX+zone)
+	}
+	return keys
+}
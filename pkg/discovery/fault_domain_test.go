@@ -0,0 +1,27 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"reflect"
+	"testing"
+)
+
+func TestTaskDomainKeys(t *testing.T) {
+	agent := &data.Agent{Id: "agent-1"}
+	setAgentRegions(&data.Domain{FaultDomain: &data.FaultDomain{Region: data.DomainName{Name: "us-east"}}},
+		[]*data.Agent{agent})
+	agent.Domain.FaultDomain.Zone.Name = "us-east-1a"
+
+	if keys := agentDomainKeys(agent); !reflect.DeepEqual(keys, []string{"region::us-east", "zone::us-east-1a"}) {
+		t.Errorf("Unexpected agent keys %v", keys)
+	}
+
+	task := &data.Task{Id: "task-1"}
+	if keys := taskDomainKeys(task, agent); len(keys) != 0 {
+		t.Errorf("Unexpected keys for task without app %v", keys)
+	}
+	task.App = &data.App{Constraints: [][]string{{"@zone", "GROUP_BY", "3"}}}
+	if keys := taskDomainKeys(task, agent); !reflect.DeepEqual(keys, []string{"zone::us-east-1a"}) {
+		t.Errorf("Unexpected keys for task with zone constraint %v", keys)
+	}
+}
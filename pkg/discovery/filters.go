@@ -0,0 +1,143 @@
+package discovery
+
+import (
+	"fmt"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"regexp"
+)
+
+// Include and exclude filters for the agents and tasks in the master state
+type DiscoveryFilter struct {
+	include *filterRules
+	exclude *filterRules
+	// Filtered agents and tasks are discovered as non controllable entities instead of being omitted
+	nonControllable bool
+}
+
+type filterRules struct {
+	*conf.FilterRules
+	hostnames []*regexp.Regexp
+}
+
+// Create the filter from the target configuration, nil if no filters are configured
+func NewDiscoveryFilter(filterConf *conf.FilterConf) (*DiscoveryFilter, error) {
+	if filterConf == nil {
+		return nil, nil
+	}
+	include, err := newFilterRules(filterConf.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := newFilterRules(filterConf.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &DiscoveryFilter{
+		include:         include,
+		exclude:         exclude,
+		nonControllable: filterConf.NonControllable,
+	}, nil
+}
+
+func newFilterRules(rulesConf *conf.FilterRules) (*filterRules, error) {
+	if rulesConf == nil {
+		return nil, nil
+	}
+	rules := &filterRules{FilterRules: rulesConf}
+	for _, hostname := range rulesConf.AgentHostnames {
+		pattern, err := regexp.Compile(hostname)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid agent hostname filter %s : %s", hostname, err)
+		}
+		rules.hostnames = append(rules.hostnames, pattern)
+	}
+	return rules, nil
+}
+
+// True if the filtered agents and tasks are discovered as non controllable entities
+func (filter *DiscoveryFilter) KeepsFiltered() bool {
+	return filter != nil && filter.nonControllable
+}
+
+// True if the agent does not match the include rules or matches the exclude rules
+func (filter *DiscoveryFilter) AgentFiltered(agent *data.Agent) bool {
+	if filter == nil {
+		return false
+	}
+	if filter.include.hasAgentRules() && !filter.include.matchesAgent(agent) {
+		return true
+	}
+	return filter.exclude.matchesAgent(agent)
+}
+
+// True if the task does not match the include rules or matches the exclude rules
+func (filter *DiscoveryFilter) TaskFiltered(task *data.Task) bool {
+	if filter == nil {
+		return false
+	}
+	if filter.include.hasTaskRules() && !filter.include.matchesTask(task) {
+		return true
+	}
+	return filter.exclude.matchesTask(task)
+}
+
+func (rules *filterRules) hasAgentRules() bool {
+	return rules != nil && (len(rules.hostnames) > 0 || len(rules.AgentAttributes) > 0)
+}
+
+func (rules *filterRules) hasTaskRules() bool {
+	return rules != nil && (len(rules.Frameworks) > 0 || len(rules.Roles) > 0 || len(rules.TaskLabels) > 0)
+}
+
+func (rules *filterRules) matchesAgent(agent *data.Agent) bool {
+	if rules == nil {
+		return false
+	}
+	for _, pattern := range rules.hostnames {
+		if pattern.MatchString(agent.Hostname) {
+			return true
+		}
+	}
+	for name, value := range rules.AgentAttributes {
+		if attribute, exists := agent.Attributes[name]; exists && formatAttributeValue(attribute) == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (rules *filterRules) matchesTask(task *data.Task) bool {
+	if rules == nil {
+		return false
+	}
+	for _, framework := range rules.Frameworks {
+		if framework == task.FrameworkName || framework == task.FrameworkId {
+			return true
+		}
+	}
+	for _, role := range rules.Roles {
+		if role == task.Role {
+			return true
+		}
+	}
+	for _, label := range task.Labels {
+		if value, exists := rules.TaskLabels[label.Key]; exists && value == label.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// Entities of the filtered agents and tasks are analyzed without actions
+func setNonControllable(entityDTO *proto.EntityDTO) {
+	if entityDTO == nil {
+		return
+	}
+	controllable := false
+	if entityDTO.ConsumerPolicy == nil {
+		entityDTO.ConsumerPolicy = &proto.EntityDTO_ConsumerPolicy{}
+	}
+	entityDTO.ConsumerPolicy.Controllable = &controllable
+}
@@ -0,0 +1,65 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"testing"
+)
+
+func TestDiscoveryFilter(t *testing.T) {
+	filter, err := NewDiscoveryFilter(&conf.FilterConf{
+		Include: &conf.FilterRules{AgentHostnames: []string{"^prod-.*"}},
+		Exclude: &conf.FilterRules{
+			Frameworks:      []string{"spark"},
+			AgentAttributes: map[string]string{"team": "data"},
+			TaskLabels:      map[string]string{"turbo": "off"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error creating filter: %s", err)
+	}
+
+	agents := []struct {
+		agent    *data.Agent
+		filtered bool
+	}{
+		{&data.Agent{Hostname: "prod-1"}, false},
+		{&data.Agent{Hostname: "dev-1"}, true},
+		{&data.Agent{Hostname: "prod-2", Attributes: map[string]interface{}{"team": "data"}}, true},
+	}
+	for _, test := range agents {
+		if filtered := filter.AgentFiltered(test.agent); filtered != test.filtered {
+			t.Errorf("Agent %s filtered %t, expected %t", test.agent.Hostname, filtered, test.filtered)
+		}
+	}
+
+	tasks := []struct {
+		task     *data.Task
+		filtered bool
+	}{
+		{&data.Task{Id: "t1", FrameworkName: "marathon"}, false},
+		{&data.Task{Id: "t2", FrameworkName: "spark"}, true},
+		{&data.Task{Id: "t3", FrameworkName: "marathon", Labels: []data.TaskLabel{{Key: "turbo", Value: "off"}}}, true},
+	}
+	for _, test := range tasks {
+		if filtered := filter.TaskFiltered(test.task); filtered != test.filtered {
+			t.Errorf("Task %s filtered %t, expected %t", test.task.Id, filtered, test.filtered)
+		}
+	}
+
+	var noFilter *DiscoveryFilter
+	if noFilter.AgentFiltered(agents[1].agent) || noFilter.TaskFiltered(tasks[1].task) {
+		t.Errorf("Nothing should be filtered without filters")
+	}
+
+	if _, err := NewDiscoveryFilter(&conf.FilterConf{Exclude: &conf.FilterRules{AgentHostnames: []string{"("}}}); err == nil {
+		t.Errorf("Expected error for the invalid hostname filter")
+	}
+
+	entityDTO := &proto.EntityDTO{}
+	setNonControllable(entityDTO)
+	if entityDTO.GetConsumerPolicy().GetControllable() {
+		t.Errorf("Entity should not be controllable")
+	}
+}
@@ -0,0 +1,15 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+// The entity for the task is controllable if the task is not excluded by the discovery filters
+// and the framework owning the task supports actions on the entity.
+// The action policy is registered per entity type, so this is the only place where the differences
+// in the actions supported by the frameworks are enforced.
+func taskControllable(task *data.Task, entityType proto.EntityDTO_EntityType) bool {
+	return !task.NonControllable && len(conf.GetFrameworkActions(task.FrameworkName, entityType)) > 0
+}
@@ -0,0 +1,27 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"testing"
+)
+
+func TestTaskControllable(t *testing.T) {
+	tests := []struct {
+		task         *data.Task
+		entityType   proto.EntityDTO_EntityType
+		controllable bool
+	}{
+		{&data.Task{FrameworkName: "marathon"}, proto.EntityDTO_CONTAINER, true},
+		{&data.Task{FrameworkName: "marathon-user"}, proto.EntityDTO_APPLICATION, true},
+		{&data.Task{FrameworkName: "metronome"}, proto.EntityDTO_APPLICATION, false},
+		{&data.Task{FrameworkName: "spark"}, proto.EntityDTO_CONTAINER, false},
+		{&data.Task{FrameworkName: "marathon", NonControllable: true}, proto.EntityDTO_CONTAINER, false},
+	}
+	for _, test := range tests {
+		if controllable := taskControllable(test.task, test.entityType); controllable != test.controllable {
+			t.Errorf("Task of %s controllable %t for %s, expected %t",
+				test.task.FrameworkName, controllable, test.entityType, test.controllable)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package discovery
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"sort"
+	"strings"
+)
+
+// Kinds of groups of the containers and applications of the tasks
+const (
+	MARATHON_GROUP  string = "marathon-group"
+	FRAMEWORK_GROUP string = "framework"
+	ROLE_GROUP      string = "role"
+)
+
+type groupKey struct {
+	entityType proto.EntityDTO_EntityType
+	kind       string
+	name       string
+}
+
+// Groups of the discovered containers and applications for each Marathon app group, framework and role
+func buildGroupDTOs(mesosMaster *data.MesosMaster, entityDTOs []*proto.EntityDTO, clusterName string) []*proto.GroupDTO {
+	var groupDTOs []*proto.GroupDTO
+	if mesosMaster == nil {
+		return groupDTOs
+	}
+	discovered := make(map[string]bool)
+	for _, entityDTO := range entityDTOs {
+		discovered[entityDTO.GetId()] = true
+	}
+
+	members := make(map[groupKey][]string)
+	for _, task := range mesosMaster.TaskMap {
+		entityIds := map[proto.EntityDTO_EntityType]string{
+			proto.EntityDTO_CONTAINER:   task.Id,
+			proto.EntityDTO_APPLICATION: getAppId(task),
+		}
+		for entityType, id := range entityIds {
+			if !discovered[id] {
+				continue
+			}
+			for _, key := range taskGroupKeys(task, entityType) {
+				members[key] = append(members[key], id)
+			}
+		}
+	}
+
+	var keys []groupKey
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return getGroupName(keys[i], clusterName) < getGroupName(keys[j], clusterName)
+	})
+	for _, key := range keys {
+		sort.Strings(members[key])
+		groupDTOs = append(groupDTOs, createGroupDTO(key, members[key], clusterName))
+	}
+	glog.V(3).Infof("[MesosDiscoveryClient] %d groups", len(groupDTOs))
+	return groupDTOs
+}
+
+// Groups of the entity of the task - the Marathon group of the app and its parent groups, the framework and the role
+func taskGroupKeys(task *data.Task, entityType proto.EntityDTO_EntityType) []groupKey {
+	var keys []groupKey
+	if task.App != nil {
+		for _, group := range marathonGroups(task.App.Name) {
+			keys = append(keys, groupKey{entityType, MARATHON_GROUP, group})
+		}
+	}
+	if task.FrameworkName != "" {
+		keys = append(keys, groupKey{entityType, FRAMEWORK_GROUP, task.FrameworkName})
+	}
+	if task.Role != "" {
+		keys = append(keys, groupKey{entityType, ROLE_GROUP, task.Role})
+	}
+	return keys
+}
+
+// Groups containing the Marathon app, /prod/payments/web is in the /prod/payments and /prod groups
+func marathonGroups(appId string) []string {
+	var groups []string
+	path := strings.Trim(appId, "/")
+	for idx := strings.LastIndex(path, "/"); idx > 0; idx = strings.LastIndex(path, "/") {
+		path = path[:idx]
+		groups = append(groups, "/"+path)
+	}
+	return groups
+}
+
+// Unique name of the group in the cluster
+func getGroupName(key groupKey, clusterName string) string {
+	return fmt.Sprintf("%s-%s-%s::%s", key.entityType, key.kind, key.name, clusterName)
+}
+
+func createGroupDTO(key groupKey, members []string, clusterName string) *proto.GroupDTO {
+	entityType := key.entityType
+	entityName := "Containers"
+	if entityType == proto.EntityDTO_APPLICATION {
+		entityName = "Applications"
+	}
+	displayName := fmt.Sprintf("Mesos %s %s %s", entityName, key.kind, key.name)
+	return &proto.GroupDTO{
+		EntityType:  &entityType,
+		DisplayName: &displayName,
+		Info:        &proto.GroupDTO_GroupName{GroupName: getGroupName(key, clusterName)},
+		Members:     &proto.GroupDTO_MemberList{MemberList: &proto.GroupDTO_MembersList{Member: members}},
+	}
+}
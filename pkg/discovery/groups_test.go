@@ -0,0 +1,42 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"reflect"
+	"testing"
+)
+
+func TestBuildGroupDTOs(t *testing.T) {
+	web := &data.Task{Id: "t1", Name: "web", FrameworkName: "marathon", Role: "prod",
+		App: &data.App{Name: "/prod/payments/web"}}
+	batch := &data.Task{Id: "t2", Name: "batch", FrameworkName: "spark", Role: "prod"}
+	mesosMaster := &data.MesosMaster{TaskMap: map[string]*data.Task{web.Id: web, batch.Id: batch}}
+
+	containerId, appId := web.Id, getAppId(web)
+	entityDTOs := []*proto.EntityDTO{{Id: &containerId}, {Id: &appId}}
+
+	groupDTOs := buildGroupDTOs(mesosMaster, entityDTOs, "cluster")
+	members := make(map[string][]string)
+	for _, groupDTO := range groupDTOs {
+		members[groupDTO.GetGroupName()] = groupDTO.GetMemberList().GetMember()
+	}
+	// 2 marathon groups, the framework and the role for the container and the application
+	if len(groupDTOs) != 8 {
+		t.Errorf("Expected 8 groups, got %d : %v", len(groupDTOs), members)
+	}
+	expected := map[string][]string{
+		"CONTAINER-marathon-group-/prod/payments::cluster": {"t1"},
+		"CONTAINER-marathon-group-/prod::cluster":          {"t1"},
+		"APPLICATION-framework-marathon::cluster":          {appId},
+		"CONTAINER-role-prod::cluster":                     {"t1"},
+	}
+	for name, expectedMembers := range expected {
+		if !reflect.DeepEqual(members[name], expectedMembers) {
+			t.Errorf("Group %s members %v, expected %v", name, members[name], expectedMembers)
+		}
+	}
+	if _, exists := members["CONTAINER-framework-spark::cluster"]; exists {
+		t.Errorf("Group should not contain the tasks that are not discovered")
+	}
+}
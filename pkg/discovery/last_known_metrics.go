@@ -0,0 +1,129 @@
+package discovery
+
+import (
+	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"sync"
+)
+
+// State of the metrics for the entities of an agent
+type MetricsState string
+
+const (
+	// Metrics collected in the current discovery
+	METRICS_CURRENT MetricsState = "current"
+	// Metrics saved in an earlier discovery, used since the agent did not respond
+	METRICS_STALE MetricsState = "stale"
+	// Agent has not responded for more than the configured number of discoveries
+	METRICS_UNAVAILABLE MetricsState = "unavailable"
+)
+
+// Metric values for all the entities on an agent saved in the last discovery the agent responded
+type AgentMetricsSnapshot struct {
+	// Number of discoveries since the agent last responded
+	failedCycles  int
+	entityMetrics map[string]map[data.ResourceType]map[data.MetricPropType]float64
+}
+
+// Cache with the last known good metrics for each agent.
+// The cached metrics are used for the agent entities for a configured number of discoveries
+// if the agent does not respond, after which the agent is marked as unavailable.
+type LastKnownMetricsCache struct {
+	maxCycles int
+
+	lock   sync.Mutex
+	agents map[string]*AgentMetricsSnapshot
+}
+
+func NewLastKnownMetricsCache(maxCycles int) *LastKnownMetricsCache {
+	return &LastKnownMetricsCache{
+		maxCycles: maxCycles,
+		agents:    make(map[string]*AgentMetricsSnapshot),
+	}
+}
+
+// Save the metrics for the agent entities if the agent responded, else restore the missing metrics
+// from the last known good metrics. The metrics state of the agent entity is updated accordingly.
+func (cache *LastKnownMetricsCache) Update(nodeRepository *NodeRepository) {
+	agentEntity := nodeRepository.agentEntity
+	entities := nodeRepository.GetEntityInstances(agentEntity.GetType())
+	entities = append(entities, nodeRepository.GetEntityInstances(proto.EntityDTO_CONTAINER)...)
+	entities = append(entities, nodeRepository.GetEntityInstances(proto.EntityDTO_APPLICATION)...)
+	entities = append(entities, nodeRepository.GetEntityInstances(proto.EntityDTO_CONTAINER_POD)...)
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if hasMetricValue(agentEntity, data.CPU, data.USED) {
+		snapshot := &AgentMetricsSnapshot{
+			entityMetrics: make(map[string]map[data.ResourceType]map[data.MetricPropType]float64),
+		}
+		for _, entity := range entities {
+			snapshot.entityMetrics[entity.GetId()] = copyMetricValues(entity.GetResourceMetrics())
+		}
+		cache.agents[agentEntity.GetId()] = snapshot
+		agentEntity.metricsState = METRICS_CURRENT
+		return
+	}
+
+	snapshot, exists := cache.agents[agentEntity.GetId()]
+	if !exists {
+		snapshot = &AgentMetricsSnapshot{}
+		cache.agents[agentEntity.GetId()] = snapshot
+	}
+	snapshot.failedCycles++
+	agentEntity.staleCycles = snapshot.failedCycles
+	if snapshot.entityMetrics == nil || snapshot.failedCycles > cache.maxCycles {
+		glog.Warningf("%s : No metrics for %d discoveries, agent is unavailable", agentEntity.GetId(), snapshot.failedCycles)
+		agentEntity.metricsState = METRICS_UNAVAILABLE
+		return
+	}
+
+	glog.Warningf("%s : Using metrics from %d discoveries ago", agentEntity.GetId(), snapshot.failedCycles)
+	for _, entity := range entities {
+		restoreMetricValues(entity, snapshot.entityMetrics[entity.GetId()])
+	}
+	agentEntity.metricsState = METRICS_STALE
+}
+
+// Discard the metrics for the agents that are not in the given list
+func (cache *LastKnownMetricsCache) RetainAgents(agentList []*data.Agent) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	agents := make(map[string]*AgentMetricsSnapshot)
+	for _, agent := range agentList {
+		if snapshot, exists := cache.agents[agent.Id]; exists {
+			agents[agent.Id] = snapshot
+		}
+	}
+	cache.agents = agents
+}
+
+func copyMetricValues(metricMap MetricMap) map[data.ResourceType]map[data.MetricPropType]float64 {
+	values := make(map[data.ResourceType]map[data.MetricPropType]float64)
+	for resourceType, resourceMap := range metricMap {
+		for metricType, metric := range resourceMap {
+			if metric == nil || metric.value == nil {
+				continue
+			}
+			if _, exists := values[resourceType]; !exists {
+				values[resourceType] = make(map[data.MetricPropType]float64)
+			}
+			values[resourceType][metricType] = *metric.value
+		}
+	}
+	return values
+}
+
+// Set the saved values for the metrics that are not set in the entity
+func restoreMetricValues(entity MesosEntity, values map[data.ResourceType]map[data.MetricPropType]float64) {
+	for resourceType, metricValues := range values {
+		for metricType, value := range metricValues {
+			if hasMetricValue(entity, resourceType, metricType) {
+				continue
+			}
+			savedValue := value
+			entity.GetResourceMetrics().SetResourceMetric(resourceType, metricType, &savedValue)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"testing"
+)
+
+func TestLastKnownMetricsCache(t *testing.T) {
+	cache := NewLastKnownMetricsCache(1)
+
+	nodeRepository := NewNodeRepository("agent-1")
+	cpuUsed := 10.0
+	nodeRepository.agentEntity.GetResourceMetrics().SetResourceMetric(data.CPU, data.USED, &cpuUsed)
+	cache.Update(nodeRepository)
+	if nodeRepository.agentEntity.metricsState != METRICS_CURRENT {
+		t.Errorf("Expected current metrics, got %s", nodeRepository.agentEntity.metricsState)
+	}
+
+	// Agent does not respond, last known metrics are used
+	nodeRepository = NewNodeRepository("agent-1")
+	cache.Update(nodeRepository)
+	if nodeRepository.agentEntity.metricsState != METRICS_STALE {
+		t.Errorf("Expected stale metrics, got %s", nodeRepository.agentEntity.metricsState)
+	}
+	checkMetricValue(t, nodeRepository.agentEntity, data.CPU, cpuUsed)
+
+	// Agent is unavailable after the configured number of discoveries
+	nodeRepository = NewNodeRepository("agent-1")
+	cache.Update(nodeRepository)
+	if nodeRepository.agentEntity.metricsState != METRICS_UNAVAILABLE {
+		t.Errorf("Expected unavailable metrics, got %s", nodeRepository.agentEntity.metricsState)
+	}
+	if hasMetricValue(nodeRepository.agentEntity, data.CPU, data.USED) {
+		t.Errorf("Unexpected metrics for unavailable agent")
+	}
+}
@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"context"
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/turbonomic/mesosturbo/pkg/conf"
@@ -22,6 +23,10 @@ type MesosLeader struct {
 
 	// The mesos state response
 	MasterState *data.MesosAPIResponse
+	// The machines scheduled for maintenance
+	MaintenanceStatus *data.MaintenanceStatus
+	// The agents recovered after a master failover that have not re-registered yet
+	RecoveredAgents []data.Agent
 
 	// Configuration of the current leader in the cluster
 	leaderConf *conf.MasterConf
@@ -152,6 +157,29 @@ func (mesosLeader *MesosLeader) RefreshMesosLeaderState() error {
 	return err
 }
 
+// Refresh the status of the machines scheduled for maintenance using the current leader.
+// The agents are discovered without the maintenance status if the request fails.
+func (mesosLeader *MesosLeader) RefreshMaintenanceStatus(ctx context.Context) {
+	maintenanceStatus, err := mesosLeader.leaderRestClient.GetMaintenanceStatus(ctx)
+	if err != nil {
+		glog.Warningf("Error getting maintenance status from leader %s : %s", mesosLeader.leaderConf.MasterIP, err)
+		maintenanceStatus = &data.MaintenanceStatus{}
+	}
+	mesosLeader.MaintenanceStatus = maintenanceStatus
+}
+
+// Refresh the agents recovered after a master failover using the current leader.
+// The recovered agents are not discovered if the request fails.
+func (mesosLeader *MesosLeader) RefreshRecoveredAgents(ctx context.Context) {
+	mesosLeader.RecoveredAgents = nil
+	agentsResp, err := mesosLeader.leaderRestClient.GetAgents(ctx)
+	if err != nil {
+		glog.Warningf("Error getting agents from leader %s : %s", mesosLeader.leaderConf.MasterIP, err)
+		return
+	}
+	mesosLeader.RecoveredAgents = agentsResp.RecoveredAgents
+}
+
 // Refresh the Mesos leader login
 // Use existing leader RestAPI client to execute the request, if not successful update the leader and login again
 func (mesosLeader *MesosLeader) RefreshMesosLeaderLogin() error {
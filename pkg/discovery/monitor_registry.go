@@ -0,0 +1,259 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"sync"
+)
+
+// =============================================== Monitor Registry ======================================
+// Function to create a monitor instance using the target configuration
+type MonitorFactory func(targetConf *conf.MesosTargetConf) (Monitor, error)
+
+// Registry of the monitors that can be enabled for a target using the monitor names in the target config
+type MonitorRegistry struct {
+	lock      sync.RWMutex
+	factories map[MONITOR_NAME]MonitorFactory
+}
+
+func NewMonitorRegistry() *MonitorRegistry {
+	return &MonitorRegistry{
+		factories: make(map[MONITOR_NAME]MonitorFactory),
+	}
+}
+
+// Registry with the monitors supported by the probe
+var DefaultMonitorRegistry = NewMonitorRegistry()
+
+func init() {
+	DefaultMonitorRegistry.Register(DEFAULT_MESOS, func(targetConf *conf.MesosTargetConf) (Monitor, error) {
+		return NewDefaultMesosMonitor(targetConf), nil
+	})
+	DefaultMonitorRegistry.Register(PROMETHEUS_MESOS, func(targetConf *conf.MesosTargetConf) (Monitor, error) {
+		return NewPrometheusMonitor(targetConf.Prometheus)
+	})
+}
+
+// Register the factory for the monitor with the given name
+func (registry *MonitorRegistry) Register(name MONITOR_NAME, factory MonitorFactory) error {
+	if factory == nil {
+		return fmt.Errorf("Null factory for monitor %s", name)
+	}
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	if _, exists := registry.factories[name]; exists {
+		return fmt.Errorf("Monitor %s is already registered", name)
+	}
+	registry.factories[name] = factory
+	return nil
+}
+
+// Create the monitors enabled in the target config, in the configured order.
+// The default Mesos monitor is used if no monitors are specified.
+func (registry *MonitorRegistry) CreateMonitors(targetConf *conf.MesosTargetConf) ([]Monitor, error) {
+	monitorNames := targetConf.Monitors
+	if len(monitorNames) == 0 {
+		monitorNames = []string{string(DEFAULT_MESOS)}
+	}
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+	var monitors []Monitor
+	for _, name := range monitorNames {
+		factory, exists := registry.factories[MONITOR_NAME(name)]
+		if !exists {
+			return nil, fmt.Errorf("Unknown monitor %s", name)
+		}
+		monitor, err := factory(targetConf)
+		if err != nil {
+			return nil, fmt.Errorf("Error creating monitor %s : %s", name, err)
+		}
+		monitors = append(monitors, monitor)
+	}
+	return monitors, nil
+}
+
+// =============================================== Monitor Group ======================================
+// Set of monitors that are executed for each agent.
+// The metrics from each monitor are recorded separately and applied to the entities in the monitor order,
+// so metrics from a later monitor override the earlier ones, or only fill the metrics not set by the
+// earlier monitors if fillMissing is enabled.
+type MonitorGroup struct {
+	monitors      []Monitor
+	executionMode conf.MonitorExecutionMode
+	fillMissing   bool
+	// Last known good metrics used when the agent does not respond, nil if disabled
+	lastKnownMetrics *LastKnownMetricsCache
+}
+
+func NewMonitorGroup(monitors []Monitor, executionMode conf.MonitorExecutionMode, fillMissing bool) *MonitorGroup {
+	return &MonitorGroup{
+		monitors:      monitors,
+		executionMode: executionMode,
+		fillMissing:   fillMissing,
+	}
+}
+
+// Use the last known good metrics for the agents that do not respond
+func (group *MonitorGroup) SetLastKnownMetricsCache(cache *LastKnownMetricsCache) {
+	group.lastKnownMetrics = cache
+}
+
+// Result of running a monitor for an agent
+type MonitorResult struct {
+	monitorName MONITOR_NAME
+	recorder    *MetricRecorder
+	err         error
+}
+
+// Run all the monitors for the target and set the metrics in the repository entities.
+// Returns the errors for each monitor.
+func (group *MonitorGroup) Monitor(ctx context.Context, target *MonitorTarget) map[MONITOR_NAME]error {
+	results := make([]*MonitorResult, len(group.monitors))
+	if group.executionMode == conf.MonitorParallel {
+		wg := new(sync.WaitGroup)
+		for idx := range group.monitors {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				results[idx] = runMonitor(ctx, group.monitors[idx], target)
+			}(idx)
+		}
+		wg.Wait()
+	} else {
+		for idx, monitor := range group.monitors {
+			results[idx] = runMonitor(ctx, monitor, target)
+		}
+	}
+
+	monitorErrors := make(map[MONITOR_NAME]error)
+	for _, result := range results {
+		if result.err != nil {
+			monitorErrors[result.monitorName] = result.err
+		}
+		result.recorder.apply(group.fillMissing)
+	}
+
+	if nodeRepository, ok := target.repository.(*NodeRepository); ok && group.lastKnownMetrics != nil {
+		group.lastKnownMetrics.Update(nodeRepository)
+	}
+	return monitorErrors
+}
+
+// Run the monitor with the metric setters replaced by a recorder for the metric values
+func runMonitor(ctx context.Context, monitor Monitor, target *MonitorTarget) *MonitorResult {
+	recorder := NewMetricRecorder()
+	monitorTarget := &MonitorTarget{
+		targetId:        target.targetId,
+		config:          target.config,
+		repository:      target.repository,
+		rawStatsCache:   target.rawStatsCache,
+		sampler:         target.sampler,
+		monitoringProps: recorder.recordingProps(target.monitoringProps),
+	}
+	err := monitor.Monitor(ctx, monitorTarget)
+	return &MonitorResult{
+		monitorName: monitor.GetSourceName(),
+		recorder:    recorder,
+		err:         err,
+	}
+}
+
+// =============================================== Metric Recorder ======================================
+// Metric value recorded by a monitor for an entity
+type RecordedMetric struct {
+	entity    MesosEntity
+	metricDef *MetricDef
+	value     *float64
+}
+
+// Records the metric values set by a monitor so they can be applied to the entities after all the monitors are done
+type MetricRecorder struct {
+	lock    sync.Mutex
+	metrics []*RecordedMetric
+}
+
+func NewMetricRecorder() *MetricRecorder {
+	return &MetricRecorder{}
+}
+
+func (recorder *MetricRecorder) record(entity MesosEntity, metricDef *MetricDef, value *float64) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.metrics = append(recorder.metrics, &RecordedMetric{
+		entity:    entity,
+		metricDef: metricDef,
+		value:     value,
+	})
+}
+
+// Copy of the monitoring properties using metric setters that record the values
+func (recorder *MetricRecorder) recordingProps(monitoringProps map[ENTITY_ID]*EntityMonitoringProps) map[ENTITY_ID]*EntityMonitoringProps {
+	if monitoringProps == nil {
+		return nil
+	}
+	recordingPropsMap := make(map[ENTITY_ID]*EntityMonitoringProps)
+	for entityId, entityProps := range monitoringProps {
+		recordingProps := &EntityMonitoringProps{
+			entityId: entityProps.entityId,
+			propMap:  make(map[PropKey]*MonitoringProperty),
+		}
+		for propKey, prop := range entityProps.propMap {
+			metricDef := prop.metricDef
+			if metricDef != nil {
+				metricDef = &MetricDef{
+					entityType:   metricDef.entityType,
+					resourceType: metricDef.resourceType,
+					metricType:   metricDef.metricType,
+					metricSetter: &RecordingMetricSetter{
+						metricDef: metricDef,
+						recorder:  recorder,
+					},
+				}
+			}
+			recordingProps.propMap[propKey] = &MonitoringProperty{
+				metricDef: metricDef,
+				id:        prop.id,
+			}
+		}
+		recordingPropsMap[entityId] = recordingProps
+	}
+	return recordingPropsMap
+}
+
+// Set the recorded values in the entities using the original metric setters
+func (recorder *MetricRecorder) apply(fillMissing bool) {
+	for _, metric := range recorder.metrics {
+		if fillMissing && hasMetricValue(metric.entity, metric.metricDef.resourceType, metric.metricDef.metricType) {
+			glog.V(4).Infof("%s::%s : Skipping metric %s:%s already set", metric.entity.GetType(), metric.entity.GetId(),
+				metric.metricDef.resourceType, metric.metricDef.metricType)
+			continue
+		}
+		if metric.metricDef.metricSetter == nil {
+			continue
+		}
+		metric.metricDef.metricSetter.SetMetricValue(metric.entity, metric.value)
+	}
+}
+
+func hasMetricValue(entity MesosEntity, resourceType data.ResourceType, metricType data.MetricPropType) bool {
+	metric, err := entity.GetResourceMetric(resourceType, metricType)
+	return err == nil && metric != nil && metric.value != nil
+}
+
+// Metric setter that saves the value in the recorder instead of the entity
+type RecordingMetricSetter struct {
+	metricDef *MetricDef
+	recorder  *MetricRecorder
+	name      string
+}
+
+func (setter *RecordingMetricSetter) SetMetricValue(entity MesosEntity, value *float64) {
+	setter.recorder.record(entity, setter.metricDef, value)
+}
+
+func (setter *RecordingMetricSetter) SetName(name string) {
+	setter.name = name
+}
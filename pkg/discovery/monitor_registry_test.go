@@ -0,0 +1,79 @@
+package discovery
+
+import (
+	"context"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"testing"
+)
+
+type fixedValueMonitor struct {
+	name  MONITOR_NAME
+	props []PropKey
+	value float64
+}
+
+func (monitor *fixedValueMonitor) GetSourceName() MONITOR_NAME {
+	return monitor.name
+}
+
+func (monitor *fixedValueMonitor) Monitor(ctx context.Context, target *MonitorTarget) error {
+	nodeRepository := target.repository.(*NodeRepository)
+	agentEntity := nodeRepository.agentEntity
+	props := target.monitoringProps[ENTITY_ID(agentEntity.GetId())]
+	ec := new(ErrorCollector)
+	for _, propKey := range monitor.props {
+		value := monitor.value
+		setValue(agentEntity, &value, propKey, props, ec)
+	}
+	return nil
+}
+
+func runMonitorGroup(executionMode conf.MonitorExecutionMode, fillMissing bool) *NodeRepository {
+	nodeRepository := NewNodeRepository("agent-1")
+	monitoringProps := createMonitoringProps(nodeRepository, NewMesosMetricsMetadataStore().metricDefMap)
+	monitors := []Monitor{
+		&fixedValueMonitor{name: "first", props: []PropKey{CPU_USED}, value: 1},
+		&fixedValueMonitor{name: "second", props: []PropKey{CPU_USED, MEM_USED}, value: 2},
+	}
+	group := NewMonitorGroup(monitors, executionMode, fillMissing)
+	group.Monitor(context.Background(), &MonitorTarget{
+		targetId:        "agent-1",
+		repository:      nodeRepository,
+		monitoringProps: monitoringProps,
+	})
+	return nodeRepository
+}
+
+func checkMetricValue(t *testing.T, entity MesosEntity, resourceType data.ResourceType, expected float64) {
+	metric, err := entity.GetResourceMetric(resourceType, data.USED)
+	if err != nil || metric.value == nil {
+		t.Fatalf("Missing %s used metric", resourceType)
+	}
+	if *metric.value != expected {
+		t.Errorf("%s used: expected %f, got %f", resourceType, expected, *metric.value)
+	}
+}
+
+func TestMonitorGroupOverride(t *testing.T) {
+	for _, mode := range []conf.MonitorExecutionMode{conf.MonitorSequential, conf.MonitorParallel} {
+		nodeRepository := runMonitorGroup(mode, false)
+		checkMetricValue(t, nodeRepository.agentEntity, data.CPU, 2)
+		checkMetricValue(t, nodeRepository.agentEntity, data.MEM, 2)
+	}
+}
+
+func TestMonitorGroupFillMissing(t *testing.T) {
+	for _, mode := range []conf.MonitorExecutionMode{conf.MonitorSequential, conf.MonitorParallel} {
+		nodeRepository := runMonitorGroup(mode, true)
+		checkMetricValue(t, nodeRepository.agentEntity, data.CPU, 1)
+		checkMetricValue(t, nodeRepository.agentEntity, data.MEM, 2)
+	}
+}
+
+func TestMonitorRegistryUnknownMonitor(t *testing.T) {
+	targetConf := &conf.MesosTargetConf{Monitors: []string{"UNKNOWN"}}
+	if _, err := DefaultMonitorRegistry.CreateMonitors(targetConf); err == nil {
+		t.Errorf("Expected error for unknown monitor")
+	}
+}
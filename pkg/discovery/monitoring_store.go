@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"context"
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/turbonomic/mesosturbo/pkg/data"
@@ -29,8 +30,10 @@ type PropKey string
 var (
 	CPU_CAP       PropKey = NewPropKey(data.CPU, data.CAP)
 	CPU_USED      PropKey = NewPropKey(data.CPU, data.USED)
+	CPU_PEAK      PropKey = NewPropKey(data.CPU, data.PEAK)
 	MEM_CAP       PropKey = NewPropKey(data.MEM, data.CAP)
 	MEM_USED      PropKey = NewPropKey(data.MEM, data.USED)
+	MEM_PEAK      PropKey = NewPropKey(data.MEM, data.PEAK)
 	CPU_PROV_CAP  PropKey = NewPropKey(data.CPU_PROV, data.CAP)
 	CPU_PROV_USED PropKey = NewPropKey(data.CPU_PROV, data.USED)
 	MEM_PROV_CAP  PropKey = NewPropKey(data.MEM_PROV, data.CAP)
@@ -65,10 +68,10 @@ type MetricSetter interface {
 }
 
 // Object that will fetch values for the given monitoring properties for all the entities in the repository
-// by connecting to the target
+// by connecting to the target, until the given context is done
 type Monitor interface {
 	GetSourceName() MONITOR_NAME
-	Monitor(target *MonitorTarget) error
+	Monitor(ctx context.Context, target *MonitorTarget) error
 }
 
 type MonitorTarget struct {
@@ -76,6 +79,7 @@ type MonitorTarget struct {
 	config          interface{}
 	repository      Repository
 	rawStatsCache   *RawStatsCache
+	sampler         *StatsSampler
 	monitoringProps map[ENTITY_ID]*EntityMonitoringProps
 }
 
@@ -169,6 +173,8 @@ func NewMesosMetricsMetadataStore() *MesosMetricsMetadataStore {
 	addDefaultMetricDef(data.NODE, data.MEM, data.CAP, resourceMap)
 	addDefaultMetricDef(data.NODE, data.CPU, data.USED, resourceMap)
 	addDefaultMetricDef(data.NODE, data.MEM, data.USED, resourceMap)
+	addDefaultMetricDef(data.NODE, data.CPU, data.PEAK, resourceMap)
+	addDefaultMetricDef(data.NODE, data.MEM, data.PEAK, resourceMap)
 	addDefaultMetricDef(data.NODE, data.CPU_PROV, data.CAP, resourceMap)
 	addDefaultMetricDef(data.NODE, data.CPU_PROV, data.USED, resourceMap)
 	addDefaultMetricDef(data.NODE, data.MEM_PROV, data.CAP, resourceMap)
@@ -180,6 +186,8 @@ func NewMesosMetricsMetadataStore() *MesosMetricsMetadataStore {
 	addDefaultMetricDef(data.CONTAINER, data.CPU, data.USED, resourceMap)
 	addDefaultMetricDef(data.CONTAINER, data.MEM, data.CAP, resourceMap)
 	addDefaultMetricDef(data.CONTAINER, data.MEM, data.USED, resourceMap)
+	addDefaultMetricDef(data.CONTAINER, data.CPU, data.PEAK, resourceMap)
+	addDefaultMetricDef(data.CONTAINER, data.MEM, data.PEAK, resourceMap)
 	addDefaultMetricDef(data.CONTAINER, data.CPU_PROV, data.CAP, resourceMap)
 	addDefaultMetricDef(data.CONTAINER, data.CPU_PROV, data.USED, resourceMap)
 	addDefaultMetricDef(data.CONTAINER, data.MEM_PROV, data.CAP, resourceMap)
@@ -196,6 +204,17 @@ func NewMesosMetricsMetadataStore() *MesosMetricsMetadataStore {
 	addDefaultMetricDef(data.APP, data.MEM_PROV, data.CAP, resourceMap)
 	addDefaultMetricDef(data.APP, data.MEM_PROV, data.USED, resourceMap)
 
+	mdMap[data.POD] = make(map[data.ResourceType]map[data.MetricPropType]*MetricDef)
+	resourceMap = mdMap[data.POD]
+	addDefaultMetricDef(data.POD, data.CPU, data.CAP, resourceMap)
+	addDefaultMetricDef(data.POD, data.CPU, data.USED, resourceMap)
+	addDefaultMetricDef(data.POD, data.MEM, data.CAP, resourceMap)
+	addDefaultMetricDef(data.POD, data.MEM, data.USED, resourceMap)
+	addDefaultMetricDef(data.POD, data.CPU, data.PEAK, resourceMap)
+	addDefaultMetricDef(data.POD, data.MEM, data.PEAK, resourceMap)
+	addDefaultMetricDef(data.POD, data.CPU_PROV, data.USED, resourceMap)
+	addDefaultMetricDef(data.POD, data.MEM_PROV, data.USED, resourceMap)
+
 	mc.metricDefMap = mdMap
 
 	return mc
@@ -268,6 +287,8 @@ func convertEntityType(entityType data.EntityType) proto.EntityDTO_EntityType {
 		return proto.EntityDTO_APPLICATION
 	} else if entityType == data.CONTAINER {
 		return proto.EntityDTO_CONTAINER
+	} else if entityType == data.POD {
+		return proto.EntityDTO_CONTAINER_POD
 	}
 	return proto.EntityDTO_UNKNOWN
 }
@@ -0,0 +1,174 @@
+package discovery
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"github.com/turbonomic/turbo-go-sdk/pkg/supplychain"
+)
+
+// Key prefix for the access commodity binding the containers of a task group to the pod
+const POD_KEY_PREFIX string = "taskgroup::"
+
+// Builder for creating Container Pod Entities to represent the Mesos Task Groups in Turbo server.
+// The pod represents the executor shared by the tasks of the group and hosts the containers for the tasks.
+type PodEntityBuilder struct {
+	nodeRepository *NodeRepository
+	errorCollector *ErrorCollector
+	agent          *data.Agent
+	stitchingConf  *conf.StitchingConf
+}
+
+// Build Container Pod EntityDTO using the task groups detected for the agent
+func (pb *PodEntityBuilder) BuildEntities() ([]*proto.EntityDTO, error) {
+	pb.errorCollector = new(ErrorCollector)
+	glog.V(3).Infof("[BuildEntities] ....")
+	result := []*proto.EntityDTO{}
+	pb.agent = pb.nodeRepository.agentEntity.node
+
+	for _, podEntity := range pb.nodeRepository.GetPodEntities() {
+		pod := podEntity.pod
+		if pod == nil {
+			pb.errorCollector.Collect(fmt.Errorf("Null pod object for entity %s", podEntity.GetId()))
+			continue
+		}
+
+		entityDTOBuilder := builder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER_POD, pod.Id).
+			DisplayName(pod.ExecutorId).
+			SellsCommodities(pb.podCommsSold(podEntity))
+
+		entityDTOBuilder = entityDTOBuilder.WithProperty(
+			newEntityProperty(supplychain.SUPPLY_CHAIN_CONSTANT_IP_ADDRESS, pb.agent.IP))
+		linkProp, err := vmLinkProperty(pb.agent, pb.stitchingConf)
+		pb.errorCollector.Collect(err)
+		if linkProp != nil {
+			entityDTOBuilder = entityDTOBuilder.WithProperty(linkProp)
+		}
+		for _, prop := range metricsStateProperties(pb.nodeRepository.agentEntity) {
+			entityDTOBuilder = entityDTOBuilder.WithProperty(prop)
+		}
+
+		entityDTOBuilder = pb.podCommoditiesBought(entityDTOBuilder, podEntity)
+		entityDTO, err := entityDTOBuilder.Create()
+		pb.errorCollector.Collect(err)
+		// The pod is controllable if the containers of all the tasks in the group are controllable
+		for _, task := range pod.TaskMap {
+			if !taskControllable(task, proto.EntityDTO_CONTAINER) {
+				setNonControllable(entityDTO)
+				break
+			}
+		}
+
+		result = append(result, entityDTO)
+	}
+	glog.V(4).Infof("[BuildEntities] Pod DTOs : %v", result)
+
+	var collectedErrors error
+	if pb.errorCollector.Count() > 0 {
+		collectedErrors = fmt.Errorf("Pod entity builder errors: %s", pb.errorCollector)
+	}
+	return result, collectedErrors
+}
+
+// Build commodityDTOs for commodity sold by the pod to the containers of the task group
+func (pb *PodEntityBuilder) podCommsSold(podEntity *PodEntity) []*proto.CommodityDTO {
+	var commoditiesSold []*proto.CommodityDTO
+
+	// VMem
+	memCap := getEntityMetricValue(podEntity, data.MEM, data.CAP, pb.errorCollector)
+	memUsed := getEntityMetricValue(podEntity, data.MEM, data.USED, pb.errorCollector)
+	vMemComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VMEM).
+		Capacity(*memCap).
+		Used(*memUsed).
+		Create()
+	pb.errorCollector.Collect(err)
+	setCommodityPeak(vMemComm, podEntity, data.MEM)
+	commoditiesSold = append(commoditiesSold, vMemComm)
+
+	// VCpu
+	cpuCap := getEntityMetricValue(podEntity, data.CPU, data.CAP, pb.errorCollector)
+	cpuUsed := getEntityMetricValue(podEntity, data.CPU, data.USED, pb.errorCollector)
+	vCpuComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VCPU).
+		Capacity(*cpuCap).
+		Used(*cpuUsed).
+		Create()
+	pb.errorCollector.Collect(err)
+	setCommodityPeak(vCpuComm, podEntity, data.CPU)
+	commoditiesSold = append(commoditiesSold, vCpuComm)
+
+	// Containers of the task group
+	podComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
+		Key(getPodKey(podEntity.pod.Id)).
+		Create()
+	pb.errorCollector.Collect(err)
+	commoditiesSold = append(commoditiesSold, podComm)
+
+	return commoditiesSold
+}
+
+// Build commodityDTOs for commodity bought by the pod from the agent
+func (pb *PodEntityBuilder) podCommoditiesBought(podDto *builder.EntityDTOBuilder, podEntity *PodEntity) *builder.EntityDTOBuilder {
+	var commoditiesBought []*proto.CommodityDTO
+
+	// MemProv
+	memProvUsed := getEntityMetricValue(podEntity, data.MEM_PROV, data.USED, pb.errorCollector)
+	memProvComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_MEM_PROVISIONED).Used(*memProvUsed).Create()
+	pb.errorCollector.Collect(err)
+	commoditiesBought = append(commoditiesBought, memProvComm)
+
+	// CpuProv
+	cpuProvUsed := getEntityMetricValue(podEntity, data.CPU_PROV, data.USED, pb.errorCollector)
+	cpuProvComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_CPU_PROVISIONED).Used(*cpuProvUsed).Create()
+	pb.errorCollector.Collect(err)
+	commoditiesBought = append(commoditiesBought, cpuProvComm)
+
+	// VMem
+	memUsed := getEntityMetricValue(podEntity, data.MEM, data.USED, pb.errorCollector)
+	vMemComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VMEM).Used(*memUsed).Create()
+	pb.errorCollector.Collect(err)
+	commoditiesBought = append(commoditiesBought, vMemComm)
+
+	// VCpu
+	cpuUsed := getEntityMetricValue(podEntity, data.CPU, data.USED, pb.errorCollector)
+	vCpuComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VCPU).Used(*cpuUsed).Create()
+	pb.errorCollector.Collect(err)
+	commoditiesBought = append(commoditiesBought, vCpuComm)
+
+	// Cluster
+	clusterCommBought, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_CLUSTER).
+		Key(pb.agent.ClusterName).
+		Create()
+	pb.errorCollector.Collect(err)
+	commoditiesBought = append(commoditiesBought, clusterCommBought)
+
+	// Role and fault domains using the tasks of the group, the tasks of a group are placed together
+	keys := make(map[string]bool)
+	for _, task := range podEntity.pod.TaskMap {
+		for _, key := range taskAccessKeys(task, pb.agent) {
+			keys[key] = true
+		}
+	}
+	for key := range keys {
+		accessComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
+			Key(key).
+			Create()
+		pb.errorCollector.Collect(err)
+		commoditiesBought = append(commoditiesBought, accessComm)
+	}
+
+	// Placement constraints of the tasks of the group
+	commoditiesBought = append(commoditiesBought, podSegmentationCommsBought(podEntity.pod, pb.agent, pb.errorCollector)...)
+
+	providerDto := builder.CreateProvider(proto.EntityDTO_VIRTUAL_MACHINE, pb.agent.Id)
+	podDto.Provider(providerDto)
+	podDto.BuysCommodities(commoditiesBought)
+
+	return podDto
+}
+
+func getPodKey(podId string) string {
+	return POD_KEY_PREFIX + podId
+}
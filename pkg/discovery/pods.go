@@ -0,0 +1,65 @@
+package discovery
+
+import (
+	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+)
+
+// Detect the task groups on the agent. The tasks of a task group are launched by the default executor
+// and share the executor container, and the custom executors of frameworks like Spark or Cassandra run
+// several tasks in one executor, so the executors hosting more than one task are represented as pods.
+func setAgentPods(agent *data.Agent) {
+	executorTasks := make(map[string][]*data.Task)
+	for _, task := range agent.TaskMap {
+		if task.ExecutorId == "" {
+			continue
+		}
+		podId := getPodId(agent.Id, task.FrameworkId, task.ExecutorId)
+		executorTasks[podId] = append(executorTasks[podId], task)
+	}
+
+	agent.PodMap = make(map[string]*data.Pod)
+	for podId, tasks := range executorTasks {
+		if len(tasks) < 2 {
+			continue
+		}
+		pod := &data.Pod{
+			Id:          podId,
+			ExecutorId:  tasks[0].ExecutorId,
+			FrameworkId: tasks[0].FrameworkId,
+			TaskMap:     make(map[string]*data.Task),
+		}
+		for _, task := range tasks {
+			task.PodId = pod.Id
+			pod.TaskMap[task.Id] = task
+			pod.Resources.CPUUnits += task.Resources.CPUUnits
+			pod.Resources.MemMB += task.Resources.MemMB
+			pod.Resources.Disk += task.Resources.Disk
+		}
+		glog.V(3).Infof("%s : Task group %s with %d tasks", agent.Id, pod.Id, len(pod.TaskMap))
+		agent.PodMap[pod.Id] = pod
+	}
+}
+
+// Id of the pod for the executor, the executor ids are only unique within a framework on an agent
+func getPodId(agentId, frameworkId, executorId string) string {
+	return agentId + "/" + frameworkId + "/" + executorId
+}
+
+// Find the task group for the executor from the agents stats query response
+func findPod(executor *data.Executor, agent *data.Agent) *data.Pod {
+	return agent.PodMap[getPodId(agent.Id, executor.FrameworkId, executor.Id)]
+}
+
+// Find the task for the nested container from the agent containers query response
+func findNestedTask(container *data.AgentContainer, pod *data.Pod) *data.Task {
+	if container.ContainerId.Parent == nil {
+		return nil
+	}
+	for _, task := range pod.TaskMap {
+		if task.ContainerId() == container.ContainerId.Value {
+			return task
+		}
+	}
+	return nil
+}
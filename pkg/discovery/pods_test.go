@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"testing"
+)
+
+func TestSetAgentPods(t *testing.T) {
+	agent := &data.Agent{
+		Id: "a1",
+		TaskMap: map[string]*data.Task{
+			"t1": {Id: "t1", FrameworkId: "f1", ExecutorId: "e1", Resources: data.Resources{CPUUnits: 1, MemMB: 128}},
+			"t2": {Id: "t2", FrameworkId: "f1", ExecutorId: "e1", Resources: data.Resources{CPUUnits: 0.5, MemMB: 64}},
+			"t3": {Id: "t3", FrameworkId: "f1", ExecutorId: "e3"},
+			"t4": {Id: "t4", FrameworkId: "f1"},
+		},
+	}
+	setAgentPods(agent)
+
+	if len(agent.PodMap) != 1 {
+		t.Fatalf("Expected one task group, got %d", len(agent.PodMap))
+	}
+	pod := agent.PodMap["a1/f1/e1"]
+	if pod == nil || len(pod.TaskMap) != 2 || pod.ExecutorId != "e1" {
+		t.Fatalf("Unexpected task group %v", pod)
+	}
+	if pod.Resources.CPUUnits != 1.5 || pod.Resources.MemMB != 192 {
+		t.Errorf("Unexpected task group resources %v", pod.Resources)
+	}
+	if agent.TaskMap["t1"].PodId != pod.Id || agent.TaskMap["t3"].PodId != "" || agent.TaskMap["t4"].PodId != "" {
+		t.Errorf("Unexpected pod ids for the tasks")
+	}
+	if findPod(&data.Executor{Id: "e1", FrameworkId: "f1"}, agent) != pod {
+		t.Errorf("Executor should match the task group")
+	}
+	if findPod(&data.Executor{Id: "e1", FrameworkId: "f2"}, agent) != nil {
+		t.Errorf("Executor of another framework should not match the task group")
+	}
+}
+
+func TestFindNestedTask(t *testing.T) {
+	task := &data.Task{
+		Id: "t1",
+		Statuses: []data.TaskStatus{
+			{State: "TASK_STARTING", Timestamp: 1,
+				ContainerStatus: data.ContainerStatus{ContainerId: &data.ContainerID{Value: "old"}}},
+			{State: "TASK_RUNNING", Timestamp: 2,
+				ContainerStatus: data.ContainerStatus{ContainerId: &data.ContainerID{Value: "c1"}}},
+		},
+	}
+	pod := &data.Pod{Id: "e1", TaskMap: map[string]*data.Task{"t1": task}}
+
+	nested := &data.AgentContainer{ContainerId: data.ContainerID{Value: "c1", Parent: &data.ContainerID{Value: "p1"}}}
+	if findNestedTask(nested, pod) != task {
+		t.Errorf("Nested container should match the task")
+	}
+	executor := &data.AgentContainer{ContainerId: data.ContainerID{Value: "c1"}}
+	if findNestedTask(executor, pod) != nil {
+		t.Errorf("Executor container should not match a task")
+	}
+}
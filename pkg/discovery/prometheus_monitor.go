@@ -0,0 +1,159 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/mesosturbo/pkg/prometheus"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"strings"
+	"time"
+)
+
+// Placeholders in the configured queries that are replaced by the agent properties
+const (
+	AGENT_IP_PLACEHOLDER       string = "$agent_ip"
+	AGENT_HOSTNAME_PLACEHOLDER string = "$agent_hostname"
+	AGENT_ID_PLACEHOLDER       string = "$agent_id"
+)
+
+// PromQL query for a metric of an entity type
+type PrometheusQuery struct {
+	entityType   data.EntityType
+	resourceType data.ResourceType
+	metricType   data.MetricPropType
+	query        string
+	taskLabel    string
+	multiplier   float64
+}
+
+// Monitor to collect the metrics for the agent entities using queries to a Prometheus server,
+// for example the metrics scraped from cAdvisor or node-exporter running on the agents
+type PrometheusMonitor struct {
+	client  *prometheus.PrometheusClient
+	queries []*PrometheusQuery
+}
+
+func NewPrometheusMonitor(prometheusConf *conf.PrometheusConf) (*PrometheusMonitor, error) {
+	if prometheusConf == nil {
+		return nil, fmt.Errorf("Missing prometheus config")
+	}
+	var queries []*PrometheusQuery
+	for _, queryConf := range prometheusConf.Queries {
+		query := &PrometheusQuery{
+			entityType:   data.EntityType(queryConf.EntityType),
+			resourceType: data.ResourceType(queryConf.ResourceType),
+			metricType:   data.MetricPropType(queryConf.MetricType),
+			query:        queryConf.Query,
+			taskLabel:    queryConf.TaskLabel,
+			multiplier:   queryConf.Multiplier,
+		}
+		if convertEntityType(query.entityType) == proto.EntityDTO_UNKNOWN {
+			return nil, fmt.Errorf("Unsupported entity type %s for prometheus query %s", query.entityType, query.query)
+		}
+		if query.entityType != data.NODE && query.taskLabel == "" {
+			return nil, fmt.Errorf("Missing task label for %s prometheus query %s", query.entityType, query.query)
+		}
+		queries = append(queries, query)
+	}
+
+	timeout := time.Duration(prometheusConf.TimeoutSecs) * time.Second
+	return &PrometheusMonitor{
+		client:  prometheus.NewPrometheusClient(prometheusConf.Url, timeout),
+		queries: queries,
+	}, nil
+}
+
+func (monitor *PrometheusMonitor) GetSourceName() MONITOR_NAME {
+	return PROMETHEUS_MESOS
+}
+
+// Implementation method for metric collection using the queries to the Prometheus server
+func (monitor *PrometheusMonitor) Monitor(ctx context.Context, target *MonitorTarget) error {
+	if target == nil {
+		return fmt.Errorf("%s: Invalid target for monitor", monitor.GetSourceName())
+	}
+	nodeRepository, ok := target.repository.(*NodeRepository)
+	if !ok {
+		return fmt.Errorf("%s: Invalid repository for monitor %s", monitor.GetSourceName(), target.targetId)
+	}
+	if target.monitoringProps == nil {
+		return fmt.Errorf("Monitoring properties not specified for the target %s", target.targetId)
+	}
+
+	agent := nodeRepository.agentEntity.node
+	replacer := strings.NewReplacer(
+		AGENT_IP_PLACEHOLDER, agent.IP,
+		AGENT_HOSTNAME_PLACEHOLDER, agent.Hostname,
+		AGENT_ID_PLACEHOLDER, agent.Id)
+
+	errorCollector := new(ErrorCollector)
+	for _, query := range monitor.queries {
+		promQL := replacer.Replace(query.query)
+		samples, err := monitor.client.Query(ctx, promQL)
+		if err != nil {
+			errorCollector.Collect(err)
+			continue
+		}
+		glog.V(4).Infof("%s : %s returned %d samples", agent.IP, promQL, len(samples))
+		monitor.setMetrics(query, samples, nodeRepository, target.monitoringProps, errorCollector)
+	}
+
+	if errorCollector.Count() > 0 {
+		return errorCollector
+	}
+	return nil
+}
+
+// Set the metric values from the query samples in the matching entities.
+// Node metrics are the sum of all the samples, container and application metrics are matched
+// to the task using the task label and summed for each task.
+func (monitor *PrometheusMonitor) setMetrics(query *PrometheusQuery, samples []*prometheus.Sample,
+	nodeRepository *NodeRepository, monitoringProps map[ENTITY_ID]*EntityMonitoringProps, ec *ErrorCollector) {
+	entityValues := make(map[string]float64)
+	entities := make(map[string]MesosEntity)
+	entityType := convertEntityType(query.entityType)
+	for _, sample := range samples {
+		var entity MesosEntity
+		if query.entityType == data.NODE {
+			entity = nodeRepository.agentEntity
+		} else {
+			taskId, exists := sample.Labels[query.taskLabel]
+			if !exists {
+				continue
+			}
+			entity = nodeRepository.GetEntity(entityType, taskId)
+		}
+		if entity == nil || isNilEntity(entity) {
+			continue
+		}
+		entities[entity.GetId()] = entity
+		entityValues[entity.GetId()] += sample.Value * query.multiplier
+	}
+
+	propKey := NewPropKey(query.resourceType, query.metricType)
+	for entityId, entity := range entities {
+		props, exists := monitoringProps[ENTITY_ID(entityId)]
+		if !exists {
+			ec.Collect(fmt.Errorf("%s::%s : Missing monitoring properties", entity.GetType(), entityId))
+			continue
+		}
+		value := entityValues[entityId]
+		setValue(entity, &value, propKey, props, ec)
+	}
+}
+
+// GetEntity returns typed nil pointers wrapped in the interface for missing tasks and containers
+func isNilEntity(entity MesosEntity) bool {
+	switch typed := entity.(type) {
+	case *TaskEntity:
+		return typed == nil
+	case *ContainerEntity:
+		return typed == nil
+	case *AgentEntity:
+		return typed == nil
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusMonitor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		var result string
+		switch {
+		case strings.Contains(query, "node_cpu{instance=\"10.0.0.1\"}"):
+			result = `{"metric":{},"value":[0,"1.5"]},{"metric":{},"value":[0,"0.5"]}`
+		case strings.Contains(query, "container_mem"):
+			result = `{"metric":{"task":"t1"},"value":[0,"2048"]},{"metric":{"task":"unknown"},"value":[0,"1"]}`
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[%s]}}`, result)
+	}))
+	defer server.Close()
+
+	monitor, err := NewPrometheusMonitor(&conf.PrometheusConf{
+		Url:         server.URL,
+		TimeoutSecs: 1,
+		Queries: []conf.PrometheusQueryConf{
+			{EntityType: string(data.NODE), ResourceType: string(data.CPU), MetricType: string(data.USED),
+				Query: `node_cpu{instance="$agent_ip"}`, Multiplier: 1000},
+			{EntityType: string(data.CONTAINER), ResourceType: string(data.MEM), MetricType: string(data.USED),
+				Query: `container_mem{agent="$agent_id"}`, TaskLabel: "task", Multiplier: 1.0 / 1024},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error creating monitor: %s", err)
+	}
+
+	nodeRepository := NewNodeRepository("a1")
+	nodeRepository.agentEntity.node = &data.Agent{Id: "a1", IP: "10.0.0.1"}
+	containerEntity := nodeRepository.CreateContainerEntity("t1")
+	target := &MonitorTarget{
+		targetId:        "a1",
+		repository:      nodeRepository,
+		monitoringProps: createMonitoringProps(nodeRepository, NewMesosMetricsMetadataStore().GetMetricDefs()),
+	}
+	if err := monitor.Monitor(context.Background(), target); err != nil {
+		t.Fatalf("Monitor error: %s", err)
+	}
+
+	// node samples are summed
+	checkPromMetric(t, nodeRepository.agentEntity, data.CPU, 2000)
+	// container samples are matched by the task label, the samples for unknown tasks are ignored
+	checkPromMetric(t, containerEntity, data.MEM, 2)
+}
+
+func TestPrometheusMonitorConfErrors(t *testing.T) {
+	for _, queryConf := range []conf.PrometheusQueryConf{
+		{EntityType: "Unknown", ResourceType: string(data.CPU), MetricType: string(data.USED), Query: "q"},
+		{EntityType: string(data.CONTAINER), ResourceType: string(data.CPU), MetricType: string(data.USED), Query: "q"},
+	} {
+		if _, err := NewPrometheusMonitor(&conf.PrometheusConf{Url: "http://prom", Queries: []conf.PrometheusQueryConf{queryConf}}); err == nil {
+			t.Errorf("Expected error for query %+v", queryConf)
+		}
+	}
+}
+
+func checkPromMetric(t *testing.T, entity MesosEntity, resourceType data.ResourceType, expected float64) {
+	metric, err := entity.GetResourceMetric(resourceType, data.USED)
+	if err != nil || metric.value == nil || *metric.value != expected {
+		t.Errorf("%s %s used: expected %f, got %+v %v", entity.GetId(), resourceType, expected, metric, err)
+	}
+}
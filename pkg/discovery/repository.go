@@ -40,10 +40,12 @@ type Metric struct {
 // Object representing the local repository of a Mesos Node or Agent.
 // It consists of an Agent entity that represents the Node. Tasks running on the node are represented using TaskEntity.
 // There is also a corresponding ContainerEntity for each task.
+// Task groups sharing an executor are represented using a PodEntity hosting the containers of the tasks.
 type NodeRepository struct {
 	agentEntity       *AgentEntity
 	taskEntities      map[string]*TaskEntity
 	containerEntities map[string]*ContainerEntity
+	podEntities       map[string]*PodEntity
 }
 
 // Create a new NodeRepository for the given Agent Id
@@ -58,12 +60,14 @@ func NewNodeRepository(agentId string) *NodeRepository {
 		agentEntity:       agentEntity,
 		taskEntities:      make(map[string]*TaskEntity),
 		containerEntities: make(map[string]*ContainerEntity),
+		podEntities:       make(map[string]*PodEntity),
 	}
 }
 
 const (
 	APP_PREFIX       string = "APP-"
 	CONTAINER_PREFIX string = "POD-"
+	POD_PREFIX       string = "TASKGROUP-"
 )
 
 func (nodeRepos *NodeRepository) CreateTaskEntity(id string) *TaskEntity {
@@ -90,6 +94,18 @@ func (nodeRepos *NodeRepository) CreateContainerEntity(id string) *ContainerEnti
 	return containerEntity
 }
 
+func (nodeRepos *NodeRepository) CreatePodEntity(id string) *PodEntity {
+	et := proto.EntityDTO_CONTAINER_POD
+	podId := GetRepositoryId(et, id)
+	podEntity := &PodEntity{
+		entityType: et,
+		id:         podId,
+		metrics:    make(map[data.ResourceType]map[data.MetricPropType]*Metric),
+	}
+	nodeRepos.podEntities[podId] = podEntity
+	return podEntity
+}
+
 func (nodeRepos *NodeRepository) GetAgentEntity() *AgentEntity {
 	return nodeRepos.agentEntity
 }
@@ -102,6 +118,10 @@ func (nodeRepos *NodeRepository) GetContainerEntities() map[string]*ContainerEnt
 	return nodeRepos.containerEntities
 }
 
+func (nodeRepos *NodeRepository) GetPodEntities() map[string]*PodEntity {
+	return nodeRepos.podEntities
+}
+
 func (nodeRepos *NodeRepository) GetEntity(entityType proto.EntityDTO_EntityType, id string) MesosEntity {
 	if entityType == proto.EntityDTO_VIRTUAL_MACHINE && nodeRepos.agentEntity.GetId() == id {
 		return nodeRepos.agentEntity
@@ -114,6 +134,10 @@ func (nodeRepos *NodeRepository) GetEntity(entityType proto.EntityDTO_EntityType
 		containerId := GetRepositoryId(proto.EntityDTO_CONTAINER, id)
 		return nodeRepos.containerEntities[containerId]
 	}
+	if entityType == proto.EntityDTO_CONTAINER_POD {
+		podId := GetRepositoryId(proto.EntityDTO_CONTAINER_POD, id)
+		return nodeRepos.podEntities[podId]
+	}
 	glog.Errorf("Entity type not found %s::%s", entityType, id)
 	return nil
 }
@@ -133,6 +157,11 @@ func (nodeRepos *NodeRepository) GetEntityInstances(entityType proto.EntityDTO_E
 			entityList = append(entityList, val)
 		}
 	}
+	if entityType == proto.EntityDTO_CONTAINER_POD {
+		for _, val := range nodeRepos.podEntities {
+			entityList = append(entityList, val)
+		}
+	}
 	return entityList
 }
 
@@ -142,6 +171,8 @@ func GetRepositoryId(entityType proto.EntityDTO_EntityType, id string) string {
 		return strings.Join([]string{APP_PREFIX, id}, "")
 	} else if entityType == proto.EntityDTO_CONTAINER {
 		return strings.Join([]string{CONTAINER_PREFIX, id}, "")
+	} else if entityType == proto.EntityDTO_CONTAINER_POD {
+		return strings.Join([]string{POD_PREFIX, id}, "")
 	}
 	return id
 }
@@ -153,6 +184,9 @@ type AgentEntity struct {
 	id         string
 	metrics    MetricMap
 	node       *data.Agent
+	// State of the metrics for the entities on the agent and the number of discoveries since the agent responded
+	metricsState MetricsState
+	staleCycles  int
 }
 
 func (agent *AgentEntity) GetId() string {
@@ -231,6 +265,34 @@ func (container *ContainerEntity) GetResourceMetric(resourceType data.ResourceTy
 	return metric, err
 }
 
+// Object representing a task group running on an Agent in the Mesos environment
+type PodEntity struct {
+	entityType proto.EntityDTO_EntityType
+	id         string
+	metrics    MetricMap
+	pod        *data.Pod
+}
+
+func (pod *PodEntity) GetId() string {
+	return pod.id
+}
+
+func (pod *PodEntity) GetType() proto.EntityDTO_EntityType {
+	return pod.entityType
+}
+
+func (pod *PodEntity) GetResourceMetrics() MetricMap {
+	return pod.metrics
+}
+
+func (pod *PodEntity) GetResourceMetric(resourceType data.ResourceType, metricType data.MetricPropType) (*Metric, error) {
+	metric, err := pod.metrics.GetResourceMetric(resourceType, metricType)
+	if err != nil {
+		err = fmt.Errorf("%s : %s", pod.id, err)
+	}
+	return metric, err
+}
+
 // =============================================== Entity Metrics ======================================
 
 func (resourceMetrics MetricMap) SetResourceMetric(resourceType data.ResourceType, metricType data.MetricPropType, value *float64) {
@@ -299,4 +361,7 @@ func PrintRepository(repository *NodeRepository) {
 	for _, containerEntity := range containerEntities {
 		PrintEntity(containerEntity)
 	}
+	for _, podEntity := range repository.GetPodEntities() {
+		PrintEntity(podEntity)
+	}
 }
@@ -0,0 +1,118 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	PM_ENTITY_PREFIX string = "PM-"
+	DC_ENTITY_PREFIX string = "DC-"
+)
+
+// In the standalone mode the agents are not stitched with the VMs discovered by an infrastructure target,
+// each agent is represented by a VM fully owning the physical machine of the agent in the cluster datacenter
+func isStandalone(stitchingConf *conf.StitchingConf) bool {
+	return stitchingConf != nil && stitchingConf.Standalone
+}
+
+// Id of the physical machine hosting the agent VM
+func getPMId(agent *data.Agent) string {
+	return PM_ENTITY_PREFIX + agent.Id
+}
+
+// Id of the datacenter for the cluster
+func getDatacenterId(clusterName string) string {
+	return DC_ENTITY_PREFIX + clusterName
+}
+
+// Disk capacity and usage of the agent VM
+func (nb *VMEntityBuilder) vmStorageComm(agentInfo *data.Agent) *proto.CommodityDTO {
+	storageComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VSTORAGE).
+		Capacity(agentInfo.Resources.Disk).
+		Used(agentInfo.UsedResources.Disk).
+		Create()
+	nb.errorCollector.Collect(err)
+	return storageComm
+}
+
+// CPU and memory bought by the agent VM from the physical machine
+func (nb *VMEntityBuilder) vmCommBought(agentEntity *AgentEntity) []*proto.CommodityDTO {
+	var commoditiesBought []*proto.CommodityDTO
+	cpuUsed := getEntityMetricValue(agentEntity, data.CPU, data.USED, nb.errorCollector)
+	cpuComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_CPU).
+		Used(*cpuUsed).
+		Create()
+	nb.errorCollector.Collect(err)
+	commoditiesBought = append(commoditiesBought, cpuComm)
+
+	memUsed := getEntityMetricValue(agentEntity, data.MEM, data.USED, nb.errorCollector)
+	memComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_MEM).
+		Used(*memUsed).
+		Create()
+	nb.errorCollector.Collect(err)
+	commoditiesBought = append(commoditiesBought, memComm)
+	return commoditiesBought
+}
+
+// Build the physical machine hosting the agent VM, the capacity of the machine is the capacity of the agent
+func (nb *VMEntityBuilder) pmEntity(agentEntity *AgentEntity) (*proto.EntityDTO, error) {
+	agentInfo := agentEntity.node
+	displayName := agentInfo.Hostname
+	if displayName == "" {
+		displayName = agentInfo.IP
+	}
+	var commoditiesSold []*proto.CommodityDTO
+	cpuCap := getEntityMetricValue(agentEntity, data.CPU, data.CAP, nb.errorCollector)
+	cpuUsed := getEntityMetricValue(agentEntity, data.CPU, data.USED, nb.errorCollector)
+	cpuComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_CPU).
+		Capacity(*cpuCap).
+		Used(*cpuUsed).
+		Create()
+	nb.errorCollector.Collect(err)
+	setCommodityPeak(cpuComm, agentEntity, data.CPU)
+	commoditiesSold = append(commoditiesSold, cpuComm)
+
+	memCap := getEntityMetricValue(agentEntity, data.MEM, data.CAP, nb.errorCollector)
+	memUsed := getEntityMetricValue(agentEntity, data.MEM, data.USED, nb.errorCollector)
+	memComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_MEM).
+		Capacity(*memCap).
+		Used(*memUsed).
+		Create()
+	nb.errorCollector.Collect(err)
+	setCommodityPeak(memComm, agentEntity, data.MEM)
+	commoditiesSold = append(commoditiesSold, memComm)
+
+	dcComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_DATACENTER).
+		Key(getDatacenterId(agentInfo.ClusterName)).
+		Create()
+	nb.errorCollector.Collect(err)
+
+	pmDTO, err := builder.NewEntityDTOBuilder(proto.EntityDTO_PHYSICAL_MACHINE, getPMId(agentInfo)).
+		DisplayName(PM_ENTITY_PREFIX + displayName).
+		SellsCommodities(commoditiesSold).
+		Provider(builder.CreateProvider(proto.EntityDTO_DATACENTER, getDatacenterId(agentInfo.ClusterName))).
+		BuysCommodity(dcComm).
+		WithPowerState(getAgentPowerState(agentInfo)).
+		Create()
+	if err == nil && agentInfo.NonControllable {
+		setNonControllable(pmDTO)
+	}
+	return pmDTO, err
+}
+
+// Build the datacenter for the cluster hosting the physical machines of the agents
+func buildDatacenterEntity(clusterName string) (*proto.EntityDTO, error) {
+	dcComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_DATACENTER).
+		Key(getDatacenterId(clusterName)).
+		Create()
+	if err != nil {
+		return nil, err
+	}
+	return builder.NewEntityDTOBuilder(proto.EntityDTO_DATACENTER, getDatacenterId(clusterName)).
+		DisplayName(clusterName).
+		SellsCommodity(dcComm).
+		Create()
+}
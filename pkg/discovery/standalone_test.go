@@ -0,0 +1,60 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"testing"
+)
+
+func TestStandaloneVMEntities(t *testing.T) {
+	nodeRepository := NewNodeRepository("a1")
+	nodeRepository.agentEntity.node = &data.Agent{
+		Id:            "a1",
+		IP:            "10.0.0.1",
+		ClusterName:   "10.0.0.10:5050",
+		State:         data.AGENT_ACTIVE,
+		Resources:     data.Resources{Disk: 1000},
+		UsedResources: data.Resources{Disk: 200},
+	}
+	for _, resourceType := range []data.ResourceType{data.CPU, data.MEM} {
+		capacity, used := 4000.0, 1000.0
+		nodeRepository.agentEntity.GetResourceMetrics().SetResourceMetric(resourceType, data.CAP, &capacity)
+		nodeRepository.agentEntity.GetResourceMetrics().SetResourceMetric(resourceType, data.USED, &used)
+	}
+
+	vmBuilder := &VMEntityBuilder{
+		nodeRepository: nodeRepository,
+		stitchingConf:  &conf.StitchingConf{Standalone: true},
+	}
+	entityDTOs, _ := vmBuilder.BuildEntities()
+	if len(entityDTOs) != 2 {
+		t.Fatalf("Expected VM and PM entities, got %d", len(entityDTOs))
+	}
+	vm, pm := entityDTOs[0], entityDTOs[1]
+	if vm.GetReplacementEntityData() != nil {
+		t.Errorf("Standalone VM should not have the replacement metadata")
+	}
+	if len(vm.GetCommoditiesBought()) != 1 || vm.GetCommoditiesBought()[0].GetProviderId() != getPMId(nodeRepository.agentEntity.node) {
+		t.Errorf("VM should buy from the physical machine %v", vm.GetCommoditiesBought())
+	}
+	var storageCap float64
+	for _, comm := range vm.GetCommoditiesSold() {
+		if comm.GetCommodityType() == proto.CommodityDTO_VSTORAGE {
+			storageCap = comm.GetCapacity()
+		}
+	}
+	if storageCap != 1000 {
+		t.Errorf("Expected storage capacity 1000, got %f", storageCap)
+	}
+
+	if pm.GetEntityType() != proto.EntityDTO_PHYSICAL_MACHINE || len(pm.GetCommoditiesBought()) != 1 ||
+		pm.GetCommoditiesBought()[0].GetProviderId() != "DC-10.0.0.10:5050" {
+		t.Errorf("Unexpected physical machine %v", pm)
+	}
+
+	dc, err := buildDatacenterEntity("10.0.0.10:5050")
+	if err != nil || dc.GetEntityType() != proto.EntityDTO_DATACENTER || dc.GetId() != "DC-10.0.0.10:5050" {
+		t.Errorf("Unexpected datacenter %v %v", dc, err)
+	}
+}
@@ -0,0 +1,340 @@
+package discovery
+
+import (
+	"context"
+	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// =============================================== Stats Sample Buffer ======================================
+// Raw statistics for a task collected at a point in time
+type StatsSample struct {
+	timestamp time.Time
+	stats     data.Statistics
+}
+
+// Fixed size ring buffer holding the most recent samples for a task
+type SampleBuffer struct {
+	samples []*StatsSample
+	next    int
+	count   int
+}
+
+func NewSampleBuffer(size int) *SampleBuffer {
+	if size < 2 {
+		size = 2
+	}
+	return &SampleBuffer{
+		samples: make([]*StatsSample, size),
+	}
+}
+
+// Add the sample to the buffer, overwriting the oldest sample when the buffer is full
+func (buffer *SampleBuffer) Add(sample *StatsSample) {
+	buffer.samples[buffer.next] = sample
+	buffer.next = (buffer.next + 1) % len(buffer.samples)
+	if buffer.count < len(buffer.samples) {
+		buffer.count++
+	}
+}
+
+// Return the samples in the buffer ordered from the oldest to the newest
+func (buffer *SampleBuffer) GetSamples() []*StatsSample {
+	ordered := make([]*StatsSample, 0, buffer.count)
+	start := (buffer.next - buffer.count + len(buffer.samples)) % len(buffer.samples)
+	for i := 0; i < buffer.count; i++ {
+		ordered = append(ordered, buffer.samples[(start+i)%len(buffer.samples)])
+	}
+	return ordered
+}
+
+// Usage values computed from the samples collected for a task.
+// CPU values are in number of CPUs used, memory values in KB.
+type SampledUse struct {
+	NumSamples      int
+	CPUAverage      float64
+	CPUPeak         float64
+	CPUPercentile   float64
+	MemAverageKB    float64
+	MemPeakKB       float64
+	MemPercentileKB float64
+}
+
+// Compute the average, peak and percentile usage using the samples collected after the given time.
+// The last sample collected before the given time is used as the starting point for the CPU usage.
+// Returns nil if there are not enough samples to compute the CPU usage.
+func (buffer *SampleBuffer) ComputeUse(since *time.Time, percentile float64) *SampledUse {
+	samples := buffer.GetSamples()
+	if since != nil {
+		start := 0
+		for idx, sample := range samples {
+			if !sample.timestamp.After(*since) {
+				start = idx
+			}
+		}
+		samples = samples[start:]
+	}
+	if len(samples) < 2 {
+		return nil
+	}
+
+	var cpuRates, memValues []float64
+	for idx := 1; idx < len(samples); idx++ {
+		prev, curr := samples[idx-1], samples[idx]
+		diffT := curr.timestamp.Sub(prev.timestamp).Seconds()
+		if diffT <= 0 {
+			continue
+		}
+		diffSecs := cpuSecs(&curr.stats) - cpuSecs(&prev.stats)
+		if diffSecs < 0 { // counters are reset when the task is restarted
+			diffSecs = data.DEFAULT_VAL
+		}
+		cpuRates = append(cpuRates, diffSecs/diffT)
+		memValues = append(memValues, curr.stats.MemRSSBytes/data.KB_MULTIPLIER)
+	}
+	if len(cpuRates) == 0 {
+		return nil
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+	cpuAverage := data.DEFAULT_VAL
+	diffSecs := cpuSecs(&last.stats) - cpuSecs(&first.stats)
+	if diffSecs > 0 {
+		cpuAverage = diffSecs / last.timestamp.Sub(first.timestamp).Seconds()
+	}
+
+	return &SampledUse{
+		NumSamples:      len(samples),
+		CPUAverage:      cpuAverage,
+		CPUPeak:         maxValue(cpuRates),
+		CPUPercentile:   percentileValue(cpuRates, percentile),
+		MemAverageKB:    averageValue(memValues),
+		MemPeakKB:       maxValue(memValues),
+		MemPercentileKB: percentileValue(memValues, percentile),
+	}
+}
+
+func cpuSecs(stats *data.Statistics) float64 {
+	return stats.CPUsystemTimeSecs + stats.CPUuserTimeSecs
+}
+
+func maxValue(values []float64) float64 {
+	max := data.DEFAULT_VAL
+	for _, value := range values {
+		max = math.Max(max, value)
+	}
+	return max
+}
+
+func averageValue(values []float64) float64 {
+	if len(values) == 0 {
+		return data.DEFAULT_VAL
+	}
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// Nearest rank percentile of the given values
+func percentileValue(values []float64, percentile float64) float64 {
+	if len(values) == 0 {
+		return data.DEFAULT_VAL
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// =============================================== Stats Sampler ======================================
+// Sampler that polls the statistics of each agent at a configured interval in the background
+// and saves the samples for each task to compute the usage at discovery time
+type StatsSampler struct {
+	samplingConf *conf.SamplingConf
+	monitor      *DefaultMesosMonitor
+	// pool shared with the discovery workers bounding the concurrent agent connections
+	pool *AgentTaskPool
+
+	lock       sync.RWMutex
+	masterConf *conf.MasterConf
+	agentList  []*data.Agent
+	// Sample buffers for each agent and task
+	taskSamples map[string]map[string]*SampleBuffer
+
+	startOnce sync.Once
+	stopCh    chan struct{}
+}
+
+func NewStatsSampler(samplingConf *conf.SamplingConf, pool *AgentTaskPool) *StatsSampler {
+	return &StatsSampler{
+		samplingConf: samplingConf,
+		monitor:      &DefaultMesosMonitor{},
+		pool:         pool,
+		taskSamples:  make(map[string]map[string]*SampleBuffer),
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Update the set of agents polled by the sampler using the latest mesos state.
+// Samples for agents and tasks that do not exist anymore are discarded.
+// Samples for the unattributed executors are kept if the executor was found in the last discovery.
+func (sampler *StatsSampler) UpdateAgents(masterConf *conf.MasterConf, agentList []*data.Agent) {
+	sampler.lock.Lock()
+	defer sampler.lock.Unlock()
+	prevExecutors := make(map[string]map[string]data.Statistics)
+	for _, agent := range sampler.agentList {
+		prevExecutors[agent.Id] = agent.ExecutorStats
+	}
+	sampler.masterConf = masterConf
+	sampler.agentList = agentList
+
+	taskSamples := make(map[string]map[string]*SampleBuffer)
+	for _, agent := range agentList {
+		prevSamples, exists := sampler.taskSamples[agent.Id]
+		if !exists {
+			continue
+		}
+		agentSamples := make(map[string]*SampleBuffer)
+		for taskId := range agent.TaskMap {
+			if buffer, ok := prevSamples[taskId]; ok {
+				agentSamples[taskId] = buffer
+			}
+		}
+		for podId := range agent.PodMap {
+			if buffer, ok := prevSamples[podId]; ok {
+				agentSamples[podId] = buffer
+			}
+		}
+		for executorKey := range prevExecutors[agent.Id] {
+			if buffer, ok := prevSamples[executorKey]; ok {
+				agentSamples[executorKey] = buffer
+			}
+		}
+		taskSamples[agent.Id] = agentSamples
+	}
+	sampler.taskSamples = taskSamples
+}
+
+// Start polling the agents in the background. Subsequent calls have no effect.
+func (sampler *StatsSampler) Start() {
+	sampler.startOnce.Do(func() {
+		interval := time.Duration(sampler.samplingConf.IntervalSecs) * time.Second
+		glog.Infof("[StatsSampler] Starting agent stats sampling every %v", interval)
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					sampler.sampleAgents()
+				case <-sampler.stopCh:
+					glog.Infof("[StatsSampler] Stopped agent stats sampling")
+					return
+				}
+			}
+		}()
+	})
+}
+
+func (sampler *StatsSampler) Stop() {
+	close(sampler.stopCh)
+}
+
+// Poll the statistics for all the agents in parallel, bounded by the slots available in the agent task pool
+func (sampler *StatsSampler) sampleAgents() {
+	sampler.lock.RLock()
+	masterConf := sampler.masterConf
+	agentList := sampler.agentList
+	sampler.lock.RUnlock()
+
+	// Samples that are not collected before the next tick are skipped
+	interval := time.Duration(sampler.samplingConf.IntervalSecs) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), interval)
+	defer cancel()
+
+	wg := new(sync.WaitGroup)
+	for idx := range agentList {
+		if agentList[idx].IsDown() {
+			continue
+		}
+		if !sampler.pool.Acquire(ctx) {
+			glog.V(3).Infof("[StatsSampler] Skipped sampling of %d agents", len(agentList)-idx)
+			break
+		}
+		wg.Add(1)
+		go func(agent *data.Agent) {
+			defer wg.Done()
+			defer sampler.pool.Release()
+			arrOfExec, err := sampler.monitor.getAgentStats(ctx, agent, masterConf)
+			if err != nil {
+				glog.V(3).Infof("[StatsSampler] Error sampling stats for agent %s::%s : %s", agent.Id, agent.IP, err)
+				return
+			}
+			timestamp := time.Now()
+			for _, executor := range arrOfExec {
+				if pod := findPod(&executor, agent); pod != nil {
+					sampler.AddSample(agent.Id, pod.Id, timestamp, executor.Statistics)
+					continue
+				}
+				task := findTask(&executor, agent.TaskMap)
+				if task == nil {
+					sampler.AddSample(agent.Id, getExecutorKey(&executor), timestamp, executor.Statistics)
+					continue
+				}
+				sampler.AddSample(agent.Id, task.Id, timestamp, executor.Statistics)
+			}
+		}(agentList[idx])
+	}
+	wg.Wait()
+}
+
+// Save the statistics for the task collected at the given time
+func (sampler *StatsSampler) AddSample(agentId, taskId string, timestamp time.Time, stats data.Statistics) {
+	sampler.lock.Lock()
+	defer sampler.lock.Unlock()
+	agentSamples, exists := sampler.taskSamples[agentId]
+	if !exists {
+		agentSamples = make(map[string]*SampleBuffer)
+		sampler.taskSamples[agentId] = agentSamples
+	}
+	buffer, exists := agentSamples[taskId]
+	if !exists {
+		buffer = NewSampleBuffer(sampler.samplingConf.BufferSize)
+		agentSamples[taskId] = buffer
+	}
+	buffer.Add(&StatsSample{timestamp: timestamp, stats: stats})
+}
+
+// Compute the usage for the task from the samples collected since the given time.
+// Returns nil if there are not enough samples for the task.
+func (sampler *StatsSampler) GetSampledUse(agentId, taskId string, since *time.Time) *SampledUse {
+	sampler.lock.RLock()
+	defer sampler.lock.RUnlock()
+	buffer, exists := sampler.taskSamples[agentId][taskId]
+	if !exists {
+		return nil
+	}
+	return buffer.ComputeUse(since, sampler.samplingConf.Percentile)
+}
+
+// Value reported as the used value using the configured sampled value type
+func (sampler *StatsSampler) usedValues(sampledUse *SampledUse) (cpuUsed, memUsedKB float64) {
+	if sampler.samplingConf.UsedValue == conf.SampledPercentile {
+		return sampledUse.CPUPercentile, sampledUse.MemPercentileKB
+	}
+	return sampledUse.CPUAverage, sampledUse.MemAverageKB
+}
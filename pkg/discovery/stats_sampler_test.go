@@ -0,0 +1,74 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"testing"
+	"time"
+)
+
+func TestSampleBufferKeepsLatestSamples(t *testing.T) {
+	buffer := NewSampleBuffer(3)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		buffer.Add(&StatsSample{timestamp: start.Add(time.Duration(i) * time.Second)})
+	}
+
+	samples := buffer.GetSamples()
+	if len(samples) != 3 {
+		t.Fatalf("Expected 3 samples, got %d", len(samples))
+	}
+	for i, sample := range samples {
+		expected := start.Add(time.Duration(i+2) * time.Second)
+		if !sample.timestamp.Equal(expected) {
+			t.Errorf("Sample %d: expected timestamp %v, got %v", i, expected, sample.timestamp)
+		}
+	}
+}
+
+func TestSampleBufferComputeUse(t *testing.T) {
+	buffer := NewSampleBuffer(10)
+	start := time.Now()
+	// cpu seconds used in each 10 second interval : 1, 5, 2, 2
+	cpuSecs := []float64{0, 1, 6, 8, 10}
+	for i, secs := range cpuSecs {
+		buffer.Add(&StatsSample{
+			timestamp: start.Add(time.Duration(i*10) * time.Second),
+			stats: data.Statistics{
+				CPUuserTimeSecs: secs,
+				MemRSSBytes:     float64(i+1) * data.KB_MULTIPLIER,
+			},
+		})
+	}
+
+	use := buffer.ComputeUse(nil, 50)
+	if use == nil {
+		t.Fatalf("Expected sampled use")
+	}
+	checkValue(t, "cpu average", 0.25, use.CPUAverage)
+	checkValue(t, "cpu peak", 0.5, use.CPUPeak)
+	checkValue(t, "cpu percentile", 0.2, use.CPUPercentile)
+	checkValue(t, "mem average", 3.5, use.MemAverageKB)
+	checkValue(t, "mem peak", 5, use.MemPeakKB)
+
+	// Only the samples after the last discovery, starting from the last sample before it
+	since := start.Add(25 * time.Second)
+	use = buffer.ComputeUse(&since, 50)
+	if use == nil {
+		t.Fatalf("Expected sampled use since %v", since)
+	}
+	checkValue(t, "cpu average since", 0.2, use.CPUAverage)
+	if use.NumSamples != 3 {
+		t.Errorf("Expected 3 samples since %v, got %d", since, use.NumSamples)
+	}
+
+	since = start.Add(60 * time.Second)
+	if use = buffer.ComputeUse(&since, 50); use != nil {
+		t.Errorf("Expected no sampled use since %v, got %+v", since, use)
+	}
+}
+
+func checkValue(t *testing.T, name string, expected, actual float64) {
+	if diff := expected - actual; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("%s: expected %f, got %f", name, expected, actual)
+	}
+}
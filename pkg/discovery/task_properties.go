@@ -0,0 +1,51 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	TASK_LABEL_NAMESPACE string = "MESOS_TASK_LABEL"
+
+	FRAMEWORK_PROPERTY      string = "framework"
+	CONTAINER_TYPE_PROPERTY string = "container-type"
+	IMAGE_PROPERTY          string = "image"
+	NETWORK_MODE_PROPERTY   string = "network-mode"
+
+	// Container type for the tasks without container info, launched by the Mesos containerizer
+	DEFAULT_CONTAINER_TYPE string = "MESOS"
+)
+
+// Properties for the container and application entities of the task, with the task labels, the framework
+// and the container type, image and network mode, used to create groups and policies in the server
+func taskProperties(task *data.Task) []*proto.EntityDTO_EntityProperty {
+	var properties []*proto.EntityDTO_EntityProperty
+	namespace := TASK_LABEL_NAMESPACE
+	for idx := range task.Labels {
+		label := task.Labels[idx]
+		properties = append(properties, &proto.EntityDTO_EntityProperty{
+			Namespace: &namespace,
+			Name:      &label.Key,
+			Value:     &label.Value,
+		})
+	}
+
+	containerType := task.Container.Type
+	if containerType == "" {
+		containerType = DEFAULT_CONTAINER_TYPE
+	}
+	values := []struct{ name, value string }{
+		{FRAMEWORK_PROPERTY, task.FrameworkName},
+		{CONTAINER_TYPE_PROPERTY, containerType},
+		{IMAGE_PROPERTY, task.Container.Image()},
+		{NETWORK_MODE_PROPERTY, task.Container.NetworkMode()},
+	}
+	for idx := range values {
+		if values[idx].value == "" {
+			continue
+		}
+		properties = append(properties, newEntityProperty(values[idx].name, values[idx].value))
+	}
+	return properties
+}
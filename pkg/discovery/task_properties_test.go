@@ -0,0 +1,58 @@
+package discovery
+
+import (
+	"encoding/json"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"testing"
+)
+
+func TestTaskProperties(t *testing.T) {
+	taskJson := `{"id": "t1", "labels": [{"key": "team", "value": "web"}],
+		"container": {"type": "MESOS", "mesos": {"image": {"type": "DOCKER", "docker": {"name": "nginx:1.17"}}},
+			"network_infos": [{"name": "dcos"}]}}`
+	var task data.Task
+	if err := json.Unmarshal([]byte(taskJson), &task); err != nil {
+		t.Fatalf("Error parsing task: %s", err)
+	}
+	task.FrameworkName = "marathon"
+
+	expected := map[string]string{
+		"team":                  "web",
+		FRAMEWORK_PROPERTY:      "marathon",
+		CONTAINER_TYPE_PROPERTY: "MESOS",
+		IMAGE_PROPERTY:          "nginx:1.17",
+		NETWORK_MODE_PROPERTY:   "USER",
+	}
+	properties := taskProperties(&task)
+	if len(properties) != len(expected) {
+		t.Errorf("Expected %d properties, got %d", len(expected), len(properties))
+	}
+	for _, prop := range properties {
+		if expected[prop.GetName()] != prop.GetValue() {
+			t.Errorf("Unexpected value %s for property %s", prop.GetValue(), prop.GetName())
+		}
+	}
+}
+
+func TestTaskIPAddresses(t *testing.T) {
+	taskJson := `{"id": "t1", "statuses": [
+		{"state": "TASK_STARTING", "timestamp": 1, "container_status": {"network_infos": [
+			{"name": "dcos", "ip_addresses": [{"protocol": "IPv4", "ip_address": "9.0.1.120"}]}]}},
+		{"state": "TASK_RUNNING", "timestamp": 3, "container_status": {"network_infos": [
+			{"name": "dcos", "ip_addresses": [{"protocol": "IPv4", "ip_address": "9.0.1.130"}, {"protocol": "IPv6", "ip_address": ""}]},
+			{"name": "overlay", "ip_addresses": [{"protocol": "IPv4", "ip_address": "10.8.0.4"}]}]}},
+		{"state": "TASK_RUNNING", "timestamp": 2, "container_status": {}}]}`
+	var task data.Task
+	if err := json.Unmarshal([]byte(taskJson), &task); err != nil {
+		t.Fatalf("Error parsing task: %s", err)
+	}
+	// addresses from the latest status with the network info
+	ipAddresses := task.IPAddresses()
+	if len(ipAddresses) != 2 || ipAddresses[0] != "9.0.1.130" || ipAddresses[1] != "10.8.0.4" {
+		t.Errorf("Unexpected container IP addresses %v", ipAddresses)
+	}
+
+	if ipAddresses := (&data.Task{Id: "t2"}).IPAddresses(); len(ipAddresses) != 0 {
+		t.Errorf("Expected no IP addresses for task without status, got %v", ipAddresses)
+	}
+}
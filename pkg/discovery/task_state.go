@@ -0,0 +1,134 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Mesos task states
+const (
+	TASK_STAGING     string = "TASK_STAGING"
+	TASK_STARTING    string = "TASK_STARTING"
+	TASK_RUNNING     string = "TASK_RUNNING"
+	TASK_KILLING     string = "TASK_KILLING"
+	TASK_UNREACHABLE string = "TASK_UNREACHABLE"
+	TASK_FAILED      string = "TASK_FAILED"
+	TASK_ERROR       string = "TASK_ERROR"
+	TASK_LOST        string = "TASK_LOST"
+	TASK_DROPPED     string = "TASK_DROPPED"
+	TASK_GONE        string = "TASK_GONE"
+)
+
+// Lifecycle state of the task reported as the entity property for the containers and applications
+type TaskLifecycle string
+
+const (
+	TASK_PROVISIONING TaskLifecycle = "PROVISIONING"
+	TASK_ACTIVE       TaskLifecycle = "ACTIVE"
+	TASK_TERMINATING  TaskLifecycle = "TERMINATING"
+	TASK_UNKNOWN      TaskLifecycle = "UNKNOWN"
+)
+
+const (
+	TASK_STATE_PROPERTY string = "task-state"
+	// Properties on the agent VM with the number of recently failed and lost tasks for each framework
+	FAILED_TASKS_PROPERTY_PREFIX string = "failed-tasks::"
+	LOST_TASKS_PROPERTY_PREFIX   string = "lost-tasks::"
+	// Completed tasks that ended within this period are counted as recently failed or lost
+	TASK_FAILURE_WINDOW time.Duration = time.Hour
+)
+
+var taskLifecycles = map[string]TaskLifecycle{
+	TASK_STAGING:     TASK_PROVISIONING,
+	TASK_STARTING:    TASK_PROVISIONING,
+	TASK_RUNNING:     TASK_ACTIVE,
+	TASK_KILLING:     TASK_TERMINATING,
+	TASK_UNREACHABLE: TASK_UNKNOWN,
+}
+
+// Lifecycle state for the task, returns false if the tasks in the given state are not discovered
+func getTaskLifecycle(task *data.Task) (TaskLifecycle, bool) {
+	lifecycle, exists := taskLifecycles[task.State]
+	return lifecycle, exists
+}
+
+// Power state of the container and application for the task, tasks that are being launched
+// or are unreachable do not have usage yet and are in unknown state
+func getTaskPowerState(lifecycle TaskLifecycle) proto.EntityDTO_PowerState {
+	switch lifecycle {
+	case TASK_ACTIVE, TASK_TERMINATING:
+		return proto.EntityDTO_POWERED_ON
+	}
+	return proto.EntityDTO_POWERSTATE_UNKNOWN
+}
+
+func taskStateProperty(lifecycle TaskLifecycle) *proto.EntityDTO_EntityProperty {
+	return newEntityProperty(TASK_STATE_PROPERTY, string(lifecycle))
+}
+
+// Count the completed tasks of the frameworks that failed or were lost on each agent within the failure window
+func setAgentTaskFailures(frameworks []data.Framework, agentMap map[string]*data.Agent, now time.Time) {
+	since := float64(now.Add(-TASK_FAILURE_WINDOW).Unix())
+	for fidx := range frameworks {
+		framework := &frameworks[fidx]
+		for idx := range framework.CompletedTasks {
+			task := &framework.CompletedTasks[idx]
+			failed := task.State == TASK_FAILED || task.State == TASK_ERROR
+			lost := task.State == TASK_LOST || task.State == TASK_DROPPED || task.State == TASK_GONE
+			if !failed && !lost {
+				continue
+			}
+			if lastStatusTime(task) < since {
+				continue
+			}
+			agent, exists := agentMap[task.SlaveId]
+			if !exists {
+				continue
+			}
+			if agent.TaskFailures == nil {
+				agent.TaskFailures = make(map[string]*data.TaskFailures)
+			}
+			failures, exists := agent.TaskFailures[framework.Name]
+			if !exists {
+				failures = &data.TaskFailures{}
+				agent.TaskFailures[framework.Name] = failures
+			}
+			if failed {
+				failures.Failed++
+			} else {
+				failures.Lost++
+			}
+		}
+	}
+}
+
+// Time of the latest status update for the task in seconds since epoch
+func lastStatusTime(task *data.Task) float64 {
+	var latest float64
+	for _, status := range task.Statuses {
+		if status.Timestamp > latest {
+			latest = status.Timestamp
+		}
+	}
+	return latest
+}
+
+// Properties with the number of recently failed and lost tasks on the agent for each framework
+func taskFailureProperties(agent *data.Agent) []*proto.EntityDTO_EntityProperty {
+	var properties []*proto.EntityDTO_EntityProperty
+	var frameworks []string
+	for name := range agent.TaskFailures {
+		frameworks = append(frameworks, name)
+	}
+	sort.Strings(frameworks)
+	for _, name := range frameworks {
+		failures := agent.TaskFailures[name]
+		properties = append(properties,
+			newEntityProperty(FAILED_TASKS_PROPERTY_PREFIX+name, strconv.Itoa(failures.Failed)),
+			newEntityProperty(LOST_TASKS_PROPERTY_PREFIX+name, strconv.Itoa(failures.Lost)))
+	}
+	return properties
+}
@@ -0,0 +1,60 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"testing"
+	"time"
+)
+
+func TestGetTaskLifecycle(t *testing.T) {
+	tests := []struct {
+		state      string
+		lifecycle  TaskLifecycle
+		discovered bool
+		powerState proto.EntityDTO_PowerState
+	}{
+		{TASK_STAGING, TASK_PROVISIONING, true, proto.EntityDTO_POWERSTATE_UNKNOWN},
+		{TASK_RUNNING, TASK_ACTIVE, true, proto.EntityDTO_POWERED_ON},
+		{TASK_KILLING, TASK_TERMINATING, true, proto.EntityDTO_POWERED_ON},
+		{TASK_UNREACHABLE, TASK_UNKNOWN, true, proto.EntityDTO_POWERSTATE_UNKNOWN},
+		{TASK_FAILED, "", false, proto.EntityDTO_POWERSTATE_UNKNOWN},
+	}
+	for _, test := range tests {
+		lifecycle, discovered := getTaskLifecycle(&data.Task{State: test.state})
+		if lifecycle != test.lifecycle || discovered != test.discovered {
+			t.Errorf("%s: expected %s %t, got %s %t", test.state, test.lifecycle, test.discovered, lifecycle, discovered)
+		}
+		if discovered && getTaskPowerState(lifecycle) != test.powerState {
+			t.Errorf("%s: expected power state %s", test.state, test.powerState)
+		}
+	}
+}
+
+func TestSetAgentTaskFailures(t *testing.T) {
+	now := time.Now()
+	recent := []data.TaskStatus{{Timestamp: float64(now.Add(-time.Minute).Unix())}}
+	old := []data.TaskStatus{{Timestamp: float64(now.Add(-2 * TASK_FAILURE_WINDOW).Unix())}}
+	frameworks := []data.Framework{
+		{
+			Name: "spark",
+			CompletedTasks: []data.Task{
+				{SlaveId: "a1", State: TASK_FAILED, Statuses: recent},
+				{SlaveId: "a1", State: TASK_LOST, Statuses: recent},
+				{SlaveId: "a1", State: TASK_FAILED, Statuses: old},
+				{SlaveId: "a1", State: "TASK_FINISHED", Statuses: recent},
+				{SlaveId: "a2", State: TASK_ERROR, Statuses: recent},
+			},
+		},
+	}
+	agentMap := map[string]*data.Agent{"a1": {Id: "a1"}}
+	setAgentTaskFailures(frameworks, agentMap, now)
+
+	failures := agentMap["a1"].TaskFailures["spark"]
+	if failures == nil || failures.Failed != 1 || failures.Lost != 1 {
+		t.Errorf("Unexpected task failures %+v", failures)
+	}
+	if len(taskFailureProperties(agentMap["a1"])) != 2 {
+		t.Errorf("Expected failed and lost task properties for the framework")
+	}
+}
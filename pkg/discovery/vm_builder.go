@@ -6,17 +6,21 @@ import (
 
 	"fmt"
 	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
 	"github.com/turbonomic/mesosturbo/pkg/data"
 )
 
+// Property with the agent IP, matched by the server against the IP addresses of the VMs discovered by the other targets
 const PROXY_VM_IP string = "Proxy_VM_IP"
 
 // Builder for creating VM Entities to represent the Mesos Agents or Slaves in Turbo server
-// This will create a proxy VM in the server. Hypervisor probes in the server will discover and manage the Agent VMs
+// This will create a proxy VM in the server. Hypervisor probes in the server will discover and manage the Agent VMs.
+// In the standalone mode the VM is hosted by a physical machine discovered with the agent.
 type VMEntityBuilder struct {
 	nodeRepository *NodeRepository
 	errorCollector *ErrorCollector
 	agent          *data.Agent
+	stitchingConf  *conf.StitchingConf
 }
 
 // Build VM EntityDTO using the agent listed in the 'state' json returned from the Mesos Master
@@ -35,10 +39,19 @@ func (nb *VMEntityBuilder) BuildEntities() ([]*proto.EntityDTO, error) {
 	commoditiesSold, err := nb.vmCommSold(nodeEntity)
 	nb.errorCollector.Collect(err)
 
-	entityDTO, err := nb.vmEntity(nb.agent, commoditiesSold)
+	entityDTO, err := nb.vmEntity(nodeEntity, commoditiesSold)
 	nb.errorCollector.Collect(err)
 
 	result = append(result, entityDTO)
+
+	// Physical machine of the agent when the agents are not stitched
+	if isStandalone(nb.stitchingConf) {
+		pmDTO, err := nb.pmEntity(nodeEntity)
+		nb.errorCollector.Collect(err)
+		if pmDTO != nil {
+			result = append(result, pmDTO)
+		}
+	}
 	glog.V(4).Infof("[BuildEntities] VM DTOs :", result)
 
 	var collectedErrors error
@@ -50,42 +63,89 @@ func (nb *VMEntityBuilder) BuildEntities() ([]*proto.EntityDTO, error) {
 }
 
 // Build VM EntityDTO
-func (nb *VMEntityBuilder) vmEntity(agentInfo *data.Agent,
+func (nb *VMEntityBuilder) vmEntity(agentEntity *AgentEntity,
 	commoditiesSold []*proto.CommodityDTO) (*proto.EntityDTO, error) {
+	agentInfo := agentEntity.node
+	displayName := agentInfo.IP
+	if displayName == "" {
+		displayName = agentInfo.Id
+	}
 	entityDTOBuilder := builder.NewEntityDTOBuilder(proto.EntityDTO_VIRTUAL_MACHINE, agentInfo.Id).
-		DisplayName(agentInfo.IP).
+		DisplayName(displayName).
 		SellsCommodities(commoditiesSold)
+	standalone := isStandalone(nb.stitchingConf)
+	if standalone {
+		entityDTOBuilder = entityDTOBuilder.
+			SellsCommodity(nb.vmStorageComm(agentInfo)).
+			Provider(builder.CreateProvider(proto.EntityDTO_PHYSICAL_MACHINE, getPMId(agentInfo))).
+			BuysCommodities(nb.vmCommBought(agentEntity))
+	}
 	// Stitching and proxy metadata
-	ipAddress := agentInfo.IP
-	ipPropName := PROXY_VM_IP // We create a different property for ip address, so the IP object in the server entity
+	stitchingKey := getVMStitchingKey(nb.stitchingConf)
+	stitchingValue, err := getVMStitchingValue(agentInfo, nb.stitchingConf)
+	if err != nil && !agentInfo.IsDown() && !standalone { // the agents that are down may not have the info
+		nb.errorCollector.Collect(err)
+	}
+	stitchingPropName := vmProxyPropertyName(stitchingKey) // We create a different property for the stitching key, so the IP object in the server entity
 	// is not deleted during reconciliation
 	// TODO: create a builder for proxy VMs
-	ipProp := &proto.EntityDTO_EntityProperty{
+	stitchingProp := &proto.EntityDTO_EntityProperty{
 		Namespace: &DEFAULT_NAMESPACE,
-		Name:      &ipPropName,
-		Value:     &ipAddress,
+		Name:      &stitchingPropName,
+		Value:     &stitchingValue,
+	}
+	if !standalone {
+		entityDTOBuilder = entityDTOBuilder.WithProperty(stitchingProp)
+	}
+	for _, prop := range metricsStateProperties(agentEntity) {
+		entityDTOBuilder = entityDTOBuilder.WithProperty(prop)
+	}
+	for _, prop := range domainProperties(agentInfo) {
+		entityDTOBuilder = entityDTOBuilder.WithProperty(prop)
+	}
+	for _, prop := range agentProperties(agentInfo) {
+		entityDTOBuilder = entityDTOBuilder.WithProperty(prop)
 	}
-	entityDTOBuilder = entityDTOBuilder.WithProperty(ipProp)
-	metaData := generateReconciliationMetaData()
+	for _, prop := range unattributedProperties(agentInfo) {
+		entityDTOBuilder = entityDTOBuilder.WithProperty(prop)
+	}
+	for _, prop := range taskFailureProperties(agentInfo) {
+		entityDTOBuilder = entityDTOBuilder.WithProperty(prop)
+	}
+	// Agent lifecycle state
+	entityDTOBuilder = entityDTOBuilder.WithProperty(newEntityProperty(AGENT_STATE_PROPERTY, string(agentInfo.State)))
+	// Agent without metrics is reported as unavailable instead of idle
+	powerState := getAgentPowerState(agentInfo)
+	if powerState == proto.EntityDTO_POWERED_ON && agentEntity.metricsState == METRICS_UNAVAILABLE {
+		powerState = proto.EntityDTO_POWERSTATE_UNKNOWN
+	}
+	entityDTOBuilder = entityDTOBuilder.WithPowerState(powerState)
+	if !standalone {
+		metaData := generateReconciliationMetaData(stitchingKey)
 
-	glog.V(3).Infof("%s: vm stitiching metadata %s", agentInfo.IP, metaData)
-	entityDTOBuilder = entityDTOBuilder.ReplacedBy(metaData)
+		glog.V(3).Infof("%s: vm stitiching metadata %s=%s %s", agentInfo.IP, stitchingKey, stitchingValue, metaData)
+		entityDTOBuilder = entityDTOBuilder.ReplacedBy(metaData)
+	}
 	entityDto, err := entityDTOBuilder.Create()
 	nb.errorCollector.Collect(err)
+	if agentInfo.NonControllable {
+		setNonControllable(entityDto)
+	}
 
 	return entityDto, nil
 }
 
-func generateReconciliationMetaData() *proto.EntityDTO_ReplacementEntityMetaData {
+func generateReconciliationMetaData(stitchingKey conf.VMStitchingKey) *proto.EntityDTO_ReplacementEntityMetaData {
 	replacementEntityMetaDataBuilder := builder.NewReplacementEntityMetaDataBuilder()
-	replacementEntityMetaDataBuilder.Matching(PROXY_VM_IP)
+	replacementEntityMetaDataBuilder.Matching(vmProxyPropertyName(stitchingKey))
 	replacementEntityMetaDataBuilder.
 		PatchSelling(proto.CommodityDTO_CPU_PROVISIONED).
 		PatchSelling(proto.CommodityDTO_MEM_PROVISIONED).
 		PatchSelling(proto.CommodityDTO_CLUSTER).
 		PatchSelling(proto.CommodityDTO_VCPU).
 		PatchSelling(proto.CommodityDTO_VMEM).
-		PatchSelling(proto.CommodityDTO_VMPM_ACCESS)
+		PatchSelling(proto.CommodityDTO_VMPM_ACCESS).
+		PatchSelling(proto.CommodityDTO_SEGMENTATION)
 	metaData := replacementEntityMetaDataBuilder.Build()
 	return metaData
 }
@@ -130,6 +190,7 @@ func (nb *VMEntityBuilder) vmCommSold(agentEntity *AgentEntity) ([]*proto.Commod
 		Used(*memUsed).
 		Create()
 	nb.errorCollector.Collect(err)
+	setCommodityPeak(vMemComm, agentEntity, data.MEM)
 	commoditiesSold = append(commoditiesSold, vMemComm)
 
 	// VCpu
@@ -140,6 +201,7 @@ func (nb *VMEntityBuilder) vmCommSold(agentEntity *AgentEntity) ([]*proto.Commod
 		Used(*cpuUsed).
 		Create()
 	nb.errorCollector.Collect(err)
+	setCommodityPeak(vCpuComm, agentEntity, data.CPU)
 	commoditiesSold = append(commoditiesSold, vCpuComm)
 
 	// Access Commodities
@@ -150,6 +212,27 @@ func (nb *VMEntityBuilder) vmCommSold(agentEntity *AgentEntity) ([]*proto.Commod
 	nb.errorCollector.Collect(err)
 	commoditiesSold = append(commoditiesSold, clusterComm)
 
+	// Roles that can use the agent resources
+	for _, role := range agentInfo.AccessRoles {
+		roleComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
+			Key(getRoleKey(role)).
+			Create()
+		nb.errorCollector.Collect(err)
+		commoditiesSold = append(commoditiesSold, roleComm)
+	}
+
+	// Region and zone of the agent
+	for _, key := range agentDomainKeys(agentInfo) {
+		domainComm, err := builder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
+			Key(key).
+			Create()
+		nb.errorCollector.Collect(err)
+		commoditiesSold = append(commoditiesSold, domainComm)
+	}
+
+	// Placement constraints of the apps satisfied by the agent
+	commoditiesSold = append(commoditiesSold, segmentationCommsSold(agentInfo, nb.errorCollector)...)
+
 	// TODO add port commodity sold
 
 	return commoditiesSold, nil
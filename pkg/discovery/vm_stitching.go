@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"fmt"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"github.com/turbonomic/turbo-go-sdk/pkg/supplychain"
+)
+
+// Property with the VM UUID or cloud instance id of the agent, matched by the server against the UUID of the VMs
+// discovered by the hypervisor and cloud targets. The UUID of the AWS and Azure VMs is the cloud instance id.
+const PROXY_VM_UUID string = "Proxy_VM_UUID"
+
+// Key used to stitch the agent VM, defaults to the agent IP if not configured
+func getVMStitchingKey(stitchingConf *conf.StitchingConf) conf.VMStitchingKey {
+	if stitchingConf == nil || stitchingConf.VMKey == "" {
+		return conf.StitchByIP
+	}
+	return stitchingConf.VMKey
+}
+
+// Value of the stitching key for the agent
+func getVMStitchingValue(agent *data.Agent, stitchingConf *conf.StitchingConf) (string, error) {
+	key := getVMStitchingKey(stitchingConf)
+	var value string
+	switch key {
+	case conf.StitchByIP:
+		value = agent.IP
+	case conf.StitchByInstanceId, conf.StitchByUUID:
+		if attribute, exists := agent.Attributes[stitchingConf.VMAttribute]; exists {
+			value = formatAttributeValue(attribute)
+		}
+	}
+	if value == "" {
+		return "", fmt.Errorf("Missing %s to stitch agent %s", key, agent.Id)
+	}
+	return value, nil
+}
+
+// Name of the property used to find the VM discovered by the other targets for which the agent VM is a proxy
+func vmProxyPropertyName(key conf.VMStitchingKey) string {
+	switch key {
+	case conf.StitchByInstanceId, conf.StitchByUUID:
+		return PROXY_VM_UUID
+	}
+	return PROXY_VM_IP
+}
+
+// Name of the property set on the entities hosted by the agent VM to find the VM, the cloud instance id
+// of the AWS and Azure VMs is the uuid of the VM
+func vmLinkPropertyName(key conf.VMStitchingKey) string {
+	switch key {
+	case conf.StitchByInstanceId, conf.StitchByUUID:
+		return supplychain.SUPPLY_CHAIN_CONSTANT_UUID
+	}
+	return supplychain.SUPPLY_CHAIN_CONSTANT_IP_ADDRESS
+}
+
+// Property for the containers and pods to find the hosting VM when the VM is not stitched by the IP.
+// The IP address property is always set on the containers and pods.
+func vmLinkProperty(agent *data.Agent, stitchingConf *conf.StitchingConf) (*proto.EntityDTO_EntityProperty, error) {
+	key := getVMStitchingKey(stitchingConf)
+	if key == conf.StitchByIP {
+		return nil, nil
+	}
+	value, err := getVMStitchingValue(agent, stitchingConf)
+	if err != nil {
+		return nil, err
+	}
+	return newEntityProperty(vmLinkPropertyName(key), value), nil
+}
@@ -0,0 +1,43 @@
+package discovery
+
+import (
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"github.com/turbonomic/turbo-go-sdk/pkg/supplychain"
+	"testing"
+)
+
+func TestVMStitchingValue(t *testing.T) {
+	agent := &data.Agent{
+		Id:         "a1",
+		IP:         "10.0.0.1",
+		Attributes: map[string]interface{}{"instance_id": "i-0abc"},
+	}
+	tests := []struct {
+		stitchingConf *conf.StitchingConf
+		value         string
+		propName      string
+	}{
+		{nil, "10.0.0.1", PROXY_VM_IP},
+		{&conf.StitchingConf{VMKey: conf.StitchByInstanceId, VMAttribute: "instance_id"}, "i-0abc", PROXY_VM_UUID},
+	}
+	for _, test := range tests {
+		value, err := getVMStitchingValue(agent, test.stitchingConf)
+		if err != nil || value != test.value {
+			t.Errorf("Expected stitching value %s, got %s %v", test.value, value, err)
+		}
+		if propName := vmProxyPropertyName(getVMStitchingKey(test.stitchingConf)); propName != test.propName {
+			t.Errorf("Expected proxy property %s, got %s", test.propName, propName)
+		}
+	}
+
+	_, err := getVMStitchingValue(agent, &conf.StitchingConf{VMKey: conf.StitchByUUID, VMAttribute: "vm_uuid"})
+	if err == nil {
+		t.Errorf("Expected error for the missing uuid attribute")
+	}
+
+	linkProp, err := vmLinkProperty(agent, &conf.StitchingConf{VMKey: conf.StitchByInstanceId, VMAttribute: "instance_id"})
+	if err != nil || linkProp.GetName() != supplychain.SUPPLY_CHAIN_CONSTANT_UUID || linkProp.GetValue() != "i-0abc" {
+		t.Errorf("Unexpected link property %v %v", linkProp, err)
+	}
+}
@@ -0,0 +1,105 @@
+package discovery
+
+import (
+	"context"
+	"github.com/golang/glog"
+	"sync"
+	"time"
+)
+
+// =============================================== Agent Task Pool ======================================
+// Pool bounding the number of agents processed concurrently across all the discovery workers.
+// Each worker processes its queue of agents using a fixed number of routines, and each routine
+// acquires a slot in the pool before processing the agent, so the total number of concurrent
+// agent connections is bounded by the pool size irrespective of the number of workers.
+type AgentTaskPool struct {
+	// slots for the agents being processed
+	slots chan struct{}
+	// number of routines processing the agent queue in each worker
+	workerConcurrency int
+
+	metrics *QueueWaitMetrics
+}
+
+func NewAgentTaskPool(maxConcurrentAgents, workerConcurrency int) *AgentTaskPool {
+	if maxConcurrentAgents < 1 {
+		maxConcurrentAgents = 1
+	}
+	if workerConcurrency < 1 {
+		workerConcurrency = 1
+	}
+	return &AgentTaskPool{
+		slots:             make(chan struct{}, maxConcurrentAgents),
+		workerConcurrency: workerConcurrency,
+		metrics:           &QueueWaitMetrics{},
+	}
+}
+
+// Wait for a free slot in the pool. Returns false if the context is done before a slot is available.
+func (pool *AgentTaskPool) Acquire(ctx context.Context) bool {
+	select {
+	case pool.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (pool *AgentTaskPool) Release() {
+	<-pool.slots
+}
+
+// Number of routines used by a worker to process the given number of agents
+func (pool *AgentTaskPool) WorkerConcurrency(numAgents int) int {
+	if numAgents < pool.workerConcurrency {
+		return numAgents
+	}
+	return pool.workerConcurrency
+}
+
+func (pool *AgentTaskPool) GetMetrics() *QueueWaitMetrics {
+	return pool.metrics
+}
+
+// Agent waiting in the worker queue
+type QueuedAgentTask struct {
+	idx        int
+	agentTask  *MesosAgentTask
+	queuedTime time.Time
+}
+
+// =============================================== Queue Wait Metrics ======================================
+// Time spent by the agents in the work queue before they are processed
+type QueueWaitMetrics struct {
+	lock      sync.Mutex
+	count     int
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+func (metrics *QueueWaitMetrics) Record(wait time.Duration) {
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+	metrics.count++
+	metrics.totalWait += wait
+	if wait > metrics.maxWait {
+		metrics.maxWait = wait
+	}
+}
+
+// Return the number of agents, average and max wait time since the last reset and reset the metrics
+func (metrics *QueueWaitMetrics) Reset() (count int, avgWait, maxWait time.Duration) {
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+	count, maxWait = metrics.count, metrics.maxWait
+	if count > 0 {
+		avgWait = metrics.totalWait / time.Duration(count)
+	}
+	metrics.count, metrics.totalWait, metrics.maxWait = 0, 0, 0
+	return count, avgWait, maxWait
+}
+
+func (metrics *QueueWaitMetrics) log() {
+	count, avgWait, maxWait := metrics.Reset()
+	glog.Infof("Agent queue wait time for %d agents : average %v, max %v", count, avgWait, maxWait)
+}
@@ -0,0 +1,75 @@
+package discovery
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAgentTaskPoolConcurrency(t *testing.T) {
+	pool := NewAgentTaskPool(2, 4)
+
+	var lock sync.Mutex
+	active, maxActive := 0, 0
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !pool.Acquire(context.Background()) {
+				t.Errorf("Expected slot to be acquired")
+				return
+			}
+			lock.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			lock.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			lock.Lock()
+			active--
+			lock.Unlock()
+			pool.Release()
+		}()
+	}
+	wg.Wait()
+	if maxActive != 2 {
+		t.Errorf("Expected at most 2 concurrent agents, got %d", maxActive)
+	}
+
+	// no slot is acquired when the context is done while the pool is full
+	pool.Acquire(context.Background())
+	pool.Acquire(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if pool.Acquire(ctx) {
+		t.Errorf("Expected no slot in the full pool")
+	}
+
+	if n := pool.WorkerConcurrency(3); n != 3 {
+		t.Errorf("Expected 3 routines for 3 agents, got %d", n)
+	}
+	if n := pool.WorkerConcurrency(10); n != 4 {
+		t.Errorf("Expected 4 routines for 10 agents, got %d", n)
+	}
+	if n := NewAgentTaskPool(0, 0).WorkerConcurrency(10); n != 1 {
+		t.Errorf("Expected 1 routine for the default pool, got %d", n)
+	}
+}
+
+func TestQueueWaitMetrics(t *testing.T) {
+	metrics := &QueueWaitMetrics{}
+	metrics.Record(10 * time.Millisecond)
+	metrics.Record(30 * time.Millisecond)
+
+	count, avgWait, maxWait := metrics.Reset()
+	if count != 2 || avgWait != 20*time.Millisecond || maxWait != 30*time.Millisecond {
+		t.Errorf("Unexpected metrics count=%d avg=%v max=%v", count, avgWait, maxWait)
+	}
+	count, avgWait, maxWait = metrics.Reset()
+	if count != 0 || avgWait != 0 || maxWait != 0 {
+		t.Errorf("Expected metrics to be reset, got count=%d avg=%v max=%v", count, avgWait, maxWait)
+	}
+}
@@ -1,6 +1,7 @@
 package master
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/golang/glog"
@@ -13,8 +14,13 @@ type AgentEndpointName string
 
 const (
 	Stats AgentEndpointName = "stats"
+	// Operator API call for the containers including the nested containers of the task groups
+	Containers AgentEndpointName = "containers"
 )
 
+// Request body for the operator API call to get the containers
+const GetContainersRequest string = `{"type":"GET_CONTAINERS","get_containers":{"show_nested":true,"show_standalone":false}}`
+
 // The endpoints used for making RestAPI calls to the Agent
 type AgentEndpoint struct {
 	EndpointName string
@@ -54,8 +60,9 @@ func NewGenericAgentAPIClient(agentConf *conf.AgentConf, masterConf *conf.Master
 
 const AgentAPIClientClass = "[AgentAPIClient] "
 
-// Make a RestAPI call to get the Mesos State using the path specified for the MasterEndpointName.Login endpoint
-func (agentRestClient *GenericAgentAPIClient) GetStats() ([]data.Executor, error) {
+// Make a RestAPI call to get the Mesos State using the path specified for the MasterEndpointName.Login endpoint.
+// The request is cancelled when the given context is done.
+func (agentRestClient *GenericAgentAPIClient) GetStats(ctx context.Context) ([]data.Executor, error) {
 	glog.V(4).Infof(AgentAPIClientClass + "Get Stats ...")
 	// Execute request
 	endpoint, _ := agentRestClient.EndpointStore.EndpointMap[Stats]
@@ -65,6 +72,7 @@ func (agentRestClient *GenericAgentAPIClient) GetStats() ([]data.Executor, error
 	if err != nil {
 		return nil, ErrorCreateRequest(AgentAPIClientClass, err)
 	}
+	request = request.WithContext(ctx)
 	glog.V(3).Infof(AgentAPIClientClass+": send GetStats() request %s ", request)
 
 	var byteContent []byte
@@ -93,6 +101,46 @@ func (agentRestClient *GenericAgentAPIClient) GetStats() ([]data.Executor, error
 	return nil, ErrorConvertResponse(AgentAPIClientClass, err)
 }
 
+// Make an operator API call to get the statistics for all the containers on the agent, including the nested
+// containers of the task groups. The request is cancelled when the given context is done.
+func (agentRestClient *GenericAgentAPIClient) GetContainers(ctx context.Context) ([]data.AgentContainer, error) {
+	glog.V(4).Infof(AgentAPIClientClass + "Get Containers ...")
+	// Debug mode does not have the container statistics
+	if agentRestClient.DebugMode {
+		return nil, nil
+	}
+	endpoint, _ := agentRestClient.EndpointStore.EndpointMap[Containers]
+	if endpoint == nil {
+		return nil, fmt.Errorf(AgentAPIClientClass + " : Missing containers endpoint")
+	}
+	request, err := createPostRequest(endpoint.EndpointPath,
+		agentRestClient.AgentConf.AgentIP, string(agentRestClient.AgentConf.AgentPort),
+		agentRestClient.MasterConf.Token, []byte(GetContainersRequest))
+	if err != nil {
+		return nil, ErrorCreateRequest(AgentAPIClientClass, err)
+	}
+	request = request.WithContext(ctx)
+	glog.V(3).Infof(AgentAPIClientClass+": send GetContainers() request %s ", request.URL)
+
+	byteContent, err := executeAndValidateResponse(request, AgentAPIClientClass)
+	if err != nil {
+		return nil, fmt.Errorf(AgentAPIClientClass+" : GetContainers() error :  %s", err)
+	}
+
+	parser := endpoint.Parser
+	err = parser.parseResponse(byteContent)
+	if err != nil {
+		return nil, ErrorParseRequest(AgentAPIClientClass, err)
+	}
+
+	msg := parser.GetMessage()
+	containerList, ok := msg.([]data.AgentContainer)
+	if ok {
+		return containerList, nil
+	}
+	return nil, ErrorConvertResponse(AgentAPIClientClass, err)
+}
+
 func (agentRestClient *GenericAgentAPIClient) getDebugModeStats() ([]byte, error) {
 	fmt.Println("========= getDebugModeStats() : DEBUG MODE =============")
 	filePath, exists := agentRestClient.DebugProps["file"]
@@ -132,3 +180,29 @@ func (parser *GenericAgentStatsParser) GetMessage() interface{} {
 	glog.V(4).Infof(GenericAgentStatsParserClass+"Agent Stats %s\n", parser.Message)
 	return parser.Message
 }
+
+// =============================================================================
+type GenericAgentContainersParser struct {
+	Message []data.AgentContainer
+}
+
+const GenericAgentContainersParserClass = "[GenericAgentContainersParser] "
+
+func (parser *GenericAgentContainersParser) parseResponse(resp []byte) error {
+	glog.V(4).Infof("%s in parse Agent Containers", GenericAgentContainersParserClass)
+	if resp == nil {
+		return ErrorEmptyResponse(GenericAgentContainersParserClass)
+	}
+	var containersResp data.AgentContainersResponse
+	err := json.Unmarshal(resp, &containersResp)
+	if err != nil {
+		return fmt.Errorf(GenericAgentContainersParserClass+" Error in json unmarshal for containers response : %s ", err)
+	}
+	parser.Message = containersResp.GetContainers.Containers
+	return nil
+}
+
+func (parser *GenericAgentContainersParser) GetMessage() interface{} {
+	glog.V(4).Infof(GenericAgentContainersParserClass+"Agent Containers %+v\n", parser.Message)
+	return parser.Message
+}
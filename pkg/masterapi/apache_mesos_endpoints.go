@@ -4,16 +4,19 @@ package master
 type ApacheMesosEndpointPath string
 
 const (
-	Apache_StatePath      ApacheMesosEndpointPath = "/state"
-	Apache_FrameworksPath ApacheMesosEndpointPath = "/frameworks"
-	Apache_TasksPath      ApacheMesosEndpointPath = "/tasks"
+	Apache_StatePath       ApacheMesosEndpointPath = "/state"
+	Apache_FrameworksPath  ApacheMesosEndpointPath = "/frameworks"
+	Apache_TasksPath       ApacheMesosEndpointPath = "/tasks"
+	Apache_MaintenancePath ApacheMesosEndpointPath = "/maintenance/status"
+	Apache_AgentsPath      ApacheMesosEndpointPath = "/slaves"
 )
 
 // Endpoint paths for Apache Agent
 type ApacheAgentEndpointPath string
 
 const (
-	Apache_StatsPath ApacheAgentEndpointPath = "/monitor/statistics.json"
+	Apache_StatsPath    ApacheAgentEndpointPath = "/monitor/statistics.json"
+	Apache_OperatorPath ApacheAgentEndpointPath = "/api/v1"
 )
 
 // Endpoint store containing endpoint and parsers for Apache Mesos Master
@@ -39,6 +42,16 @@ func NewApacheMesosEndpointStore() *MasterEndpointStore {
 		EndpointPath: string(Apache_TasksPath),
 		Parser:       &GenericMasterStateParser{},
 	}
+	epMap[MaintenanceStatus] = &MasterEndpoint{
+		EndpointName: string(MaintenanceStatus),
+		EndpointPath: string(Apache_MaintenancePath),
+		Parser:       &MaintenanceStatusParser{},
+	}
+	epMap[Agents] = &MasterEndpoint{
+		EndpointName: string(Agents),
+		EndpointPath: string(Apache_AgentsPath),
+		Parser:       &AgentsParser{},
+	}
 
 	return store
 }
@@ -56,5 +69,10 @@ func NewApacheAgentEndpointStore() *AgentEndpointStore {
 		EndpointPath: string(Apache_StatsPath),
 		Parser:       &GenericAgentStatsParser{},
 	}
+	epMap[Containers] = &AgentEndpoint{
+		EndpointName: string(Containers),
+		EndpointPath: string(Apache_OperatorPath),
+		Parser:       &GenericAgentContainersParser{},
+	}
 	return store
 }
@@ -11,17 +11,20 @@ import (
 type DCOSEndpointPath string
 
 const (
-	DCOS_StatePath      DCOSEndpointPath = "/mesos/state"
-	DCOS_FrameworksPath DCOSEndpointPath = "/mesos/frameworks"
-	DCOS_TasksPath      DCOSEndpointPath = "/mesos/tasks"
-	DCOS_LoginPath      DCOSEndpointPath = "/acs/api/v1/auth/login"
+	DCOS_StatePath       DCOSEndpointPath = "/mesos/state"
+	DCOS_FrameworksPath  DCOSEndpointPath = "/mesos/frameworks"
+	DCOS_TasksPath       DCOSEndpointPath = "/mesos/tasks"
+	DCOS_MaintenancePath DCOSEndpointPath = "/mesos/maintenance/status"
+	DCOS_AgentsPath      DCOSEndpointPath = "/mesos/slaves"
+	DCOS_LoginPath       DCOSEndpointPath = "/acs/api/v1/auth/login"
 )
 
 // Endpoint paths for Apache Agent
 type DCOSAgentEndpointPath string
 
 const (
-	DCOS_StatsPath DCOSAgentEndpointPath = "/monitor/statistics.json"
+	DCOS_StatsPath    DCOSAgentEndpointPath = "/monitor/statistics.json"
+	DCOS_OperatorPath DCOSAgentEndpointPath = "/api/v1"
 )
 
 // Endpoint store containing endpoint and parsers for DCOS Mesos Master
@@ -51,6 +54,16 @@ func NewDCOSMesosEndpointStore() *MasterEndpointStore {
 		EndpointName: string(Tasks),
 		EndpointPath: string(DCOS_TasksPath),
 	}
+	epMap[MaintenanceStatus] = &MasterEndpoint{
+		EndpointName: string(MaintenanceStatus),
+		EndpointPath: string(DCOS_MaintenancePath),
+		Parser:       &MaintenanceStatusParser{},
+	}
+	epMap[Agents] = &MasterEndpoint{
+		EndpointName: string(Agents),
+		EndpointPath: string(DCOS_AgentsPath),
+		Parser:       &AgentsParser{},
+	}
 
 	return store
 }
@@ -68,6 +81,11 @@ func NewDCOSAgentEndpointStore() *AgentEndpointStore {
 		EndpointPath: string(DCOS_StatsPath),
 		Parser:       &GenericAgentStatsParser{},
 	}
+	epMap[Containers] = &AgentEndpoint{
+		EndpointName: string(Containers),
+		EndpointPath: string(DCOS_OperatorPath),
+		Parser:       &GenericAgentContainersParser{},
+	}
 	return store
 }
 
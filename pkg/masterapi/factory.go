@@ -1,6 +1,7 @@
 package master
 
 import (
+	"context"
 	"github.com/golang/glog"
 	"github.com/turbonomic/mesosturbo/pkg/conf"
 	"github.com/turbonomic/mesosturbo/pkg/data"
@@ -10,11 +11,14 @@ import (
 type MasterRestClient interface {
 	Login() (string, error)
 	GetState() (*data.MesosAPIResponse, error)
+	GetMaintenanceStatus(ctx context.Context) (*data.MaintenanceStatus, error)
+	GetAgents(ctx context.Context) (*data.MesosAgentsResponse, error)
 }
 
 // Interface for the client to handle Rest API communication with the Agent
 type AgentRestClient interface {
-	GetStats() ([]data.Executor, error)
+	GetStats(ctx context.Context) ([]data.Executor, error)
+	GetContainers(ctx context.Context) ([]data.AgentContainer, error)
 }
 
 // Get the Rest API client to handle communication with the Mesos Master
@@ -0,0 +1,78 @@
+package master
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"net/http"
+)
+
+// Endpoint paths for Marathon
+const (
+	Marathon_AppsPath string = "/v2/apps?embed=apps.tasks"
+	// Marathon service path on the DCOS master
+	DCOS_MarathonPath string = "/service/marathon"
+)
+
+const MarathonClientClass = "[MarathonClient] "
+
+// Client for the Marathon Rest API used to get the apps and their tasks
+type MarathonClient struct {
+	baseUrl string
+	// DCOS login token
+	token string
+	// Credentials for Marathon with basic authentication
+	username string
+	password string
+}
+
+// Create the client for Marathon using the framework configuration in the target conf.
+// For DCOS, Marathon is accessed through the leader master using the login token.
+// Returns nil if Marathon is not configured for the target.
+func NewMarathonClient(targetConf *conf.MesosTargetConf, masterConf *conf.MasterConf) *MarathonClient {
+	if targetConf.Master == conf.DCOS && masterConf != nil {
+		return &MarathonClient{
+			baseUrl: "http://" + masterConf.MasterIP + DCOS_MarathonPath,
+			token:   masterConf.Token,
+		}
+	}
+	if targetConf.Framework == conf.Marathon && targetConf.FrameworkIP != "" {
+		return &MarathonClient{
+			baseUrl:  "http://" + targetConf.FrameworkIP + ":" + targetConf.FrameworkPort,
+			username: targetConf.FrameworkUser,
+			password: targetConf.FrameworkPassword,
+		}
+	}
+	return nil
+}
+
+// Get the apps with their tasks, the request is cancelled when the given context is done
+func (client *MarathonClient) GetApps(ctx context.Context) ([]data.App, error) {
+	request, err := http.NewRequest("GET", client.baseUrl+Marathon_AppsPath, nil)
+	if err != nil {
+		return nil, ErrorCreateRequest(MarathonClientClass, err)
+	}
+	request.Header.Add("Content-type", "application/json")
+	if client.token != "" {
+		request.Header.Add("Authorization", "token="+client.token)
+	} else if client.username != "" {
+		request.SetBasicAuth(client.username, client.password)
+	}
+	request = request.WithContext(ctx)
+	glog.V(3).Infof(MarathonClientClass+"send GetApps() request %s ", request.URL)
+
+	byteContent, err := executeAndCheckStatus(request, MarathonClientClass+"GetApps()")
+	if err != nil {
+		return nil, err
+	}
+
+	var appsResponse data.AppsResponse
+	err = json.Unmarshal(byteContent, &appsResponse)
+	if err != nil {
+		return nil, fmt.Errorf(MarathonClientClass+"Error in json unmarshal for apps response : %s", err)
+	}
+	return appsResponse.Apps, nil
+}
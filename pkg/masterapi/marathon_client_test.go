@@ -0,0 +1,43 @@
+package master
+
+import (
+	"context"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestMarathonClient(server *httptest.Server) *MarathonClient {
+	address := strings.Split(strings.TrimPrefix(server.URL, "http://"), ":")
+	return NewMarathonClient(&conf.MesosTargetConf{
+		FrameworkConf: conf.FrameworkConf{Framework: conf.Marathon, FrameworkIP: address[0], FrameworkPort: address[1]},
+	}, nil)
+}
+
+func TestMarathonGetApps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"apps": [{"id": "/prod/web", "constraints": [["hostname", "UNIQUE"]], "tasks": []}]}`))
+	}))
+	defer server.Close()
+
+	apps, err := newTestMarathonClient(server).GetApps(context.Background())
+	if err != nil {
+		t.Fatalf("Error getting apps : %s", err)
+	}
+	if len(apps) != 1 || apps[0].Name != "/prod/web" || len(apps[0].Constraints) != 1 {
+		t.Errorf("Unexpected apps %+v", apps)
+	}
+}
+
+func TestMarathonGetAppsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Invalid username or password."}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	if apps, err := newTestMarathonClient(server).GetApps(context.Background()); err == nil {
+		t.Errorf("Expected error for the unauthorized response, got apps %+v", apps)
+	}
+}
@@ -2,6 +2,7 @@ package master
 
 import (
 	"bytes"
+	"context"
 	"github.com/golang/glog"
 	"net/http"
 
@@ -20,6 +21,10 @@ const (
 	State      MasterEndpointName = "state"
 	Frameworks MasterEndpointName = "frameworks"
 	Tasks      MasterEndpointName = "tasks"
+	// Machines scheduled for maintenance
+	MaintenanceStatus MasterEndpointName = "maintenance-status"
+	// Registered agents and the agents recovered after a master failover
+	Agents MasterEndpointName = "agents"
 )
 
 // The endpoints used for making RestAPI calls to the Mesos Master
@@ -146,6 +151,88 @@ func (mesosRestClient *GenericMasterAPIClient) GetState() (*data.MesosAPIRespons
 	return nil, ErrorConvertResponse(MesosMasterAPIClientClass, err)
 }
 
+// Make a RestAPI call to get the status of the machines scheduled for maintenance.
+// The request is cancelled when the given context is done.
+// Returns the status as MaintenanceStatus object if successful, else error
+func (mesosRestClient *GenericMasterAPIClient) GetMaintenanceStatus(ctx context.Context) (*data.MaintenanceStatus, error) {
+	glog.V(4).Infof("[GenericMasterAPIClient] Get Maintenance Status ...")
+	// Debug mode does not have the maintenance status
+	if mesosRestClient.DebugMode {
+		return &data.MaintenanceStatus{}, nil
+	}
+	endpoint, _ := mesosRestClient.EndpointStore.EndpointMap[MaintenanceStatus]
+	if endpoint == nil {
+		return nil, fmt.Errorf("%s : Missing maintenance status endpoint", MesosMasterAPIClientClass)
+	}
+	request, err := createRequest(endpoint.EndpointPath,
+		mesosRestClient.MasterConf.MasterIP, mesosRestClient.MasterConf.MasterPort,
+		mesosRestClient.MasterConf.Token)
+	if err != nil {
+		return nil, ErrorCreateRequest(MesosMasterAPIClientClass, err)
+	}
+	request = request.WithContext(ctx)
+	glog.V(3).Infof(MesosMasterAPIClientClass+" : send GetMaintenanceStatus() request %s ", request.URL)
+
+	byteContent, err := executeAndCheckStatus(request, MesosMasterAPIClientClass+":GetMaintenanceStatus()")
+	if err != nil {
+		return nil, fmt.Errorf("%s", err)
+	}
+
+	parser := endpoint.Parser
+	err = parser.parseResponse(byteContent)
+	if err != nil {
+		return nil, ErrorParseRequest(MesosMasterAPIClientClass, err)
+	}
+
+	msg := parser.GetMessage()
+	st, ok := msg.(*data.MaintenanceStatus)
+	if ok {
+		return st, nil
+	}
+	return nil, ErrorConvertResponse(MesosMasterAPIClientClass, err)
+}
+
+// Make a RestAPI call to get the agents registered with the master and the agents recovered from the registry
+// after a master failover. The request is cancelled when the given context is done.
+// Returns the agents as MesosAgentsResponse object if successful, else error
+func (mesosRestClient *GenericMasterAPIClient) GetAgents(ctx context.Context) (*data.MesosAgentsResponse, error) {
+	glog.V(4).Infof("[GenericMasterAPIClient] Get Agents ...")
+	// Debug mode does not have the agents response
+	if mesosRestClient.DebugMode {
+		return &data.MesosAgentsResponse{}, nil
+	}
+	endpoint, _ := mesosRestClient.EndpointStore.EndpointMap[Agents]
+	if endpoint == nil {
+		return nil, fmt.Errorf("%s : Missing agents endpoint", MesosMasterAPIClientClass)
+	}
+	request, err := createRequest(endpoint.EndpointPath,
+		mesosRestClient.MasterConf.MasterIP, mesosRestClient.MasterConf.MasterPort,
+		mesosRestClient.MasterConf.Token)
+	if err != nil {
+		return nil, ErrorCreateRequest(MesosMasterAPIClientClass, err)
+	}
+	request = request.WithContext(ctx)
+	glog.V(3).Infof(MesosMasterAPIClientClass+" : send GetAgents() request %s ", request.URL)
+
+	byteContent, err := executeAndCheckStatus(request, MesosMasterAPIClientClass+":GetAgents()")
+	if err != nil {
+		return nil, fmt.Errorf("%s", err)
+	}
+
+	parser := endpoint.Parser
+	err = parser.parseResponse(byteContent)
+	if err != nil {
+		return nil, ErrorParseRequest(MesosMasterAPIClientClass, err)
+	}
+
+	msg := parser.GetMessage()
+	st, ok := msg.(*data.MesosAgentsResponse)
+	if ok {
+		return st, nil
+	}
+	return nil, ErrorConvertResponse(MesosMasterAPIClientClass, err)
+}
+
 func createRequest(endpoint, ip, port, token string) (*http.Request, error) {
 	fullUrl := "http://" + ip + ":" + port + endpoint //TODO: handle https requests
 	req, err := http.NewRequest("GET", fullUrl, nil)
@@ -159,6 +246,20 @@ func createRequest(endpoint, ip, port, token string) (*http.Request, error) {
 	return req, nil
 }
 
+func createPostRequest(endpoint, ip, port, token string, body []byte) (*http.Request, error) {
+	fullUrl := "http://" + ip + ":" + port + endpoint
+	req, err := http.NewRequest("POST", fullUrl, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-type", "application/json")
+	req.Header.Add("Accept", "application/json")
+	if token != "" {
+		req.Header.Add("Authorization", "token="+token)
+	}
+	return req, nil
+}
+
 func executeAndValidateResponse(request *http.Request, logPrefix string) ([]byte, error) {
 	var byteContent []byte
 	var resp *http.Response
@@ -188,6 +289,25 @@ func executeAndValidateResponse(request *http.Request, logPrefix string) ([]byte
 	return byteContent, nil
 }
 
+// Execute the request and return the response content, the responses with a non 2xx status are rejected
+func executeAndCheckStatus(request *http.Request, logPrefix string) ([]byte, error) {
+	client := &http.Client{}
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, ErrorExecuteRequest(logPrefix, err)
+	}
+	defer resp.Body.Close()
+
+	byteContent, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf(logPrefix+" Error in ioutil.ReadAll: %s", err)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf(logPrefix+" Request failed with status %s : %s", resp.Status, byteContent)
+	}
+	return byteContent, nil
+}
+
 func (mesosRestClient *GenericMasterAPIClient) createLoginRequest(endpoint string) (*http.Request, error) {
 	var jsonStr []byte
 	url := "http://" + mesosRestClient.MasterConf.MasterIP + endpoint
@@ -246,3 +366,58 @@ func (parser *GenericMasterStateParser) GetMessage() interface{} {
 	glog.V(4).Infof(GenericMasterStateParserClass+" Mesos State %s\n", parser.Message)
 	return parser.Message
 }
+
+// ========================================= Maintenance Status Parser ===================================================
+
+type MaintenanceStatusParser struct {
+	Message *data.MaintenanceStatus
+}
+
+const MaintenanceStatusParserClass = "[MaintenanceStatusParser]"
+
+func (parser *MaintenanceStatusParser) parseResponse(resp []byte) error {
+	glog.V(4).Infof("%s in parseResponse : %s", MaintenanceStatusParserClass, resp)
+	if resp == nil {
+		return ErrorEmptyResponse(MaintenanceStatusParserClass)
+	}
+
+	var maintenanceStatus data.MaintenanceStatus
+	err := json.Unmarshal(resp, &maintenanceStatus)
+	if err != nil {
+		return fmt.Errorf(MaintenanceStatusParserClass+" Error in json unmarshal for maintenance status response : %s", err)
+	}
+	parser.Message = &maintenanceStatus
+	return nil
+}
+
+func (parser *MaintenanceStatusParser) GetMessage() interface{} {
+	glog.V(4).Infof(MaintenanceStatusParserClass+" Maintenance Status %s\n", parser.Message)
+	return parser.Message
+}
+
+// ========================================= Agents Parser ===================================================
+
+type AgentsParser struct {
+	Message *data.MesosAgentsResponse
+}
+
+const AgentsParserClass = "[AgentsParser]"
+
+func (parser *AgentsParser) parseResponse(resp []byte) error {
+	glog.V(4).Infof("%s in parseResponse : %s", AgentsParserClass, resp)
+	if resp == nil {
+		return ErrorEmptyResponse(AgentsParserClass)
+	}
+
+	var agentsResponse data.MesosAgentsResponse
+	err := json.Unmarshal(resp, &agentsResponse)
+	if err != nil {
+		return fmt.Errorf(AgentsParserClass+" Error in json unmarshal for agents response : %s", err)
+	}
+	parser.Message = &agentsResponse
+	return nil
+}
+
+func (parser *AgentsParser) GetMessage() interface{} {
+	return parser.Message
+}
@@ -0,0 +1,243 @@
+package master
+
+import (
+	"context"
+	"github.com/turbonomic/mesosturbo/pkg/conf"
+	"github.com/turbonomic/mesosturbo/pkg/data"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Master state of a Mesos 1.x cluster with one registered and one unreachable agent
+const stateResponse = `{
+  "version": "1.4.2",
+  "git_sha": "ee36a6a3bc1a3a36d4bab2e8b2fd98f5e0ec6d57",
+  "build_date": "2018-08-27 15:06:13",
+  "build_time": 1535382373,
+  "build_user": "",
+  "start_time": 1536672937.53372,
+  "elected_time": 1536672937.5637,
+  "id": "6ec5f9fc-c5a6-4a9b-9cd0-1f3bb3e1e0c4",
+  "pid": "master@10.0.0.10:5050",
+  "hostname": "10.0.0.10",
+  "activated_slaves": 1,
+  "deactivated_slaves": 0,
+  "unreachable_slaves": 1,
+  "leader": "master@10.0.0.10:5050",
+  "leader_info": {
+    "id": "6ec5f9fc-c5a6-4a9b-9cd0-1f3bb3e1e0c4",
+    "pid": "master@10.0.0.10:5050",
+    "port": 5050,
+    "hostname": "10.0.0.10"
+  },
+  "log_dir": "/var/log/mesos",
+  "flags": {"authenticate_agents": "false", "port": "5050"},
+  "slaves": [
+    {
+      "id": "d5a3b7e6-1c8f-4f3d-9a35-0bbd3a9c6c2e-S1",
+      "pid": "slave(1)@10.0.0.11:5051",
+      "hostname": "agent-1.example.com",
+      "registered_time": 1536672940.12,
+      "resources": {"disk": 35164.0, "mem": 14881.0, "gpus": 0.0, "cpus": 4.0, "ports": "[1025-2180, 2182-3887, 3889-5049, 5052-8079, 8082-8180, 8182-32000]"},
+      "used_resources": {"disk": 0.0, "mem": 128.0, "gpus": 0.0, "cpus": 0.1, "ports": "[31522-31522]"},
+      "offered_resources": {"disk": 0.0, "mem": 0.0, "gpus": 0.0, "cpus": 0.0},
+      "reserved_resources": {},
+      "unreserved_resources": {"disk": 35164.0, "mem": 14881.0, "gpus": 0.0, "cpus": 4.0, "ports": "[1025-2180, 2182-3887, 3889-5049, 5052-8079, 8082-8180, 8182-32000]"},
+      "attributes": {"rack": "r1", "cpu_mhz": 2400},
+      "active": true,
+      "version": "1.4.2",
+      "capabilities": ["MULTI_ROLE", "HIERARCHICAL_ROLE", "RESERVATION_REFINEMENT"]
+    }
+  ],
+  "frameworks": [
+    {
+      "id": "d5a3b7e6-1c8f-4f3d-9a35-0bbd3a9c6c2e-0001",
+      "name": "marathon",
+      "pid": "scheduler-2f5f3a25-7a77-4d3a-8f39-8a4b3a2b1c3d@10.0.0.10:15101",
+      "used_resources": {"disk": 0.0, "mem": 128.0, "gpus": 0.0, "cpus": 0.1, "ports": "[31522-31522]"},
+      "offered_resources": {"disk": 0.0, "mem": 0.0, "gpus": 0.0, "cpus": 0.0},
+      "capabilities": ["TASK_KILLING_STATE", "PARTITION_AWARE"],
+      "hostname": "10.0.0.10",
+      "webui_url": "http://10.0.0.10:8080",
+      "active": true,
+      "connected": true,
+      "recovered": false,
+      "role": "*",
+      "user": "root",
+      "failover_timeout": 604800.0,
+      "checkpoint": true,
+      "registered_time": 1536672945.48,
+      "unregistered_time": 0.0,
+      "resources": {"disk": 0.0, "mem": 128.0, "gpus": 0.0, "cpus": 0.1, "ports": "[31522-31522]"},
+      "tasks": [
+        {
+          "id": "web.5b1e2a4c-b5d6-11e8-9a8d-70b3d5800001",
+          "name": "web",
+          "framework_id": "d5a3b7e6-1c8f-4f3d-9a35-0bbd3a9c6c2e-0001",
+          "executor_id": "",
+          "slave_id": "d5a3b7e6-1c8f-4f3d-9a35-0bbd3a9c6c2e-S1",
+          "state": "TASK_RUNNING",
+          "resources": {"disk": 0.0, "mem": 128.0, "gpus": 0.0, "cpus": 0.1, "ports": "[31522-31522]"},
+          "role": "*",
+          "statuses": [{"state": "TASK_RUNNING", "timestamp": 1536672950.71}]
+        }
+      ],
+      "unreachable_tasks": [
+        {
+          "id": "db.6c2f3b5d-b5d6-11e8-9a8d-70b3d5800001",
+          "name": "db",
+          "framework_id": "d5a3b7e6-1c8f-4f3d-9a35-0bbd3a9c6c2e-0001",
+          "executor_id": "",
+          "slave_id": "d5a3b7e6-1c8f-4f3d-9a35-0bbd3a9c6c2e-S2",
+          "state": "TASK_UNREACHABLE",
+          "resources": {"disk": 0.0, "mem": 512.0, "gpus": 0.0, "cpus": 0.5},
+          "role": "*",
+          "statuses": [{"state": "TASK_UNREACHABLE", "timestamp": 1536673950.12}]
+        }
+      ],
+      "completed_tasks": [],
+      "offers": [],
+      "executors": []
+    }
+  ],
+  "completed_frameworks": [],
+  "orphan_tasks": [],
+  "unregistered_frameworks": []
+}`
+
+// Agents of the master after a failover, with one agent recovered from the registry
+const agentsResponse = `{
+  "slaves": [
+    {
+      "id": "d5a3b7e6-1c8f-4f3d-9a35-0bbd3a9c6c2e-S1",
+      "pid": "slave(1)@10.0.0.11:5051",
+      "hostname": "agent-1.example.com",
+      "registered_time": 1536672940.12,
+      "resources": {"disk": 35164.0, "mem": 14881.0, "gpus": 0.0, "cpus": 4.0},
+      "attributes": {},
+      "active": true,
+      "version": "1.4.2"
+    }
+  ],
+  "recovered_slaves": [
+    {
+      "id": "d5a3b7e6-1c8f-4f3d-9a35-0bbd3a9c6c2e-S3",
+      "hostname": "10.0.0.13",
+      "port": 5051,
+      "resources": {"disk": 35164.0, "mem": 14881.0, "gpus": 0.0, "cpus": 4.0},
+      "attributes": {"rack": "r2"}
+    }
+  ]
+}`
+
+func TestParseStateResponse(t *testing.T) {
+	parser := &GenericMasterStateParser{}
+	if err := parser.parseResponse([]byte(stateResponse)); err != nil {
+		t.Fatalf("Error parsing state response : %s", err)
+	}
+	state := parser.GetMessage().(*data.MesosAPIResponse)
+
+	if state.UnreachableAgents != 1 {
+		t.Errorf("Expected 1 unreachable agent, got %f", state.UnreachableAgents)
+	}
+	if state.LeaderInfo.Hostname != "10.0.0.10" || state.LeaderInfo.Port != 5050 {
+		t.Errorf("Unexpected leader %+v", state.LeaderInfo)
+	}
+	if len(state.Agents) != 1 {
+		t.Fatalf("Expected 1 agent, got %d", len(state.Agents))
+	}
+	agent := state.Agents[0]
+	if agent.Pid != "slave(1)@10.0.0.11:5051" || agent.Hostname != "agent-1.example.com" || !agent.Active {
+		t.Errorf("Unexpected agent %+v", agent)
+	}
+	if agent.Resources.CPUUnits != 4 || agent.UsedResources.MemMB != 128 {
+		t.Errorf("Unexpected agent resources %+v used %+v", agent.Resources, agent.UsedResources)
+	}
+	if len(state.Frameworks) != 1 {
+		t.Fatalf("Expected 1 framework, got %d", len(state.Frameworks))
+	}
+	framework := state.Frameworks[0]
+	if len(framework.Tasks) != 1 || framework.Tasks[0].State != "TASK_RUNNING" {
+		t.Errorf("Unexpected framework tasks %+v", framework.Tasks)
+	}
+	if len(framework.UnreachableTasks) != 1 || framework.UnreachableTasks[0].SlaveId != "d5a3b7e6-1c8f-4f3d-9a35-0bbd3a9c6c2e-S2" {
+		t.Errorf("Unexpected framework unreachable tasks %+v", framework.UnreachableTasks)
+	}
+}
+
+func TestParseAgentsResponse(t *testing.T) {
+	parser := &AgentsParser{}
+	if err := parser.parseResponse([]byte(agentsResponse)); err != nil {
+		t.Fatalf("Error parsing agents response : %s", err)
+	}
+	agents := parser.GetMessage().(*data.MesosAgentsResponse)
+
+	if len(agents.Agents) != 1 {
+		t.Errorf("Expected 1 registered agent, got %d", len(agents.Agents))
+	}
+	if len(agents.RecoveredAgents) != 1 {
+		t.Fatalf("Expected 1 recovered agent, got %d", len(agents.RecoveredAgents))
+	}
+	recovered := agents.RecoveredAgents[0]
+	if recovered.Id != "d5a3b7e6-1c8f-4f3d-9a35-0bbd3a9c6c2e-S3" || recovered.Hostname != "10.0.0.13" ||
+		recovered.Port != 5051 || recovered.Pid != "" {
+		t.Errorf("Unexpected recovered agent %+v", recovered)
+	}
+}
+
+func newTestMasterClient(t *testing.T, server *httptest.Server) MasterRestClient {
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Invalid test server address : %s", err)
+	}
+	return NewGenericMasterAPIClient(&conf.MasterConf{MasterIP: host, MasterPort: port}, NewApacheMesosEndpointStore())
+}
+
+func TestGetAgents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != string(Apache_AgentsPath) {
+			t.Errorf("Unexpected request path %s", r.URL.Path)
+		}
+		w.Write([]byte(agentsResponse))
+	}))
+	defer server.Close()
+
+	agents, err := newTestMasterClient(t, server).GetAgents(context.Background())
+	if err != nil {
+		t.Fatalf("Error getting agents : %s", err)
+	}
+	if len(agents.RecoveredAgents) != 1 {
+		t.Errorf("Expected 1 recovered agent, got %d", len(agents.RecoveredAgents))
+	}
+}
+
+func TestGetMaintenanceStatusErrors(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not the leading master", http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	if _, err := newTestMasterClient(t, unavailable).GetMaintenanceStatus(context.Background()); err == nil {
+		t.Errorf("Expected error for the service unavailable response")
+	}
+
+	done := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer hanging.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := newTestMasterClient(t, hanging).GetMaintenanceStatus(ctx); err == nil {
+		t.Errorf("Expected error for the request cancelled by the context")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Request was not cancelled by the context, took %s", elapsed)
+	}
+}
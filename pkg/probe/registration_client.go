@@ -5,28 +5,41 @@ import (
 	// turbo sdk imports
 	"github.com/turbonomic/mesosturbo/pkg/conf"
 	"github.com/turbonomic/turbo-go-sdk/pkg/builder"
-	"github.com/turbonomic/turbo-go-sdk/pkg/probe"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	"github.com/turbonomic/turbo-go-sdk/pkg/supplychain"
 )
 
 // Registration Client for the Mesos Probe
-// Implements the TurboRegistrationClient interface
+// Implements the TurboRegistrationClient and IActionPolicyProvider interfaces
 type MesosRegistrationClient struct {
 	mesosMasterType conf.MesosMasterType
+	// Agent property used to stitch the agent VMs
+	vmStitchingKey conf.VMStitchingKey
+	// Agent VMs hosted by physical machines discovered by the probe
+	standalone bool
 }
 
-func NewRegistrationClient(mesosMasterType conf.MesosMasterType) probe.TurboRegistrationClient {
+func NewRegistrationClient(mesosMasterType conf.MesosMasterType, stitchingConf *conf.StitchingConf) *MesosRegistrationClient {
 	client := &MesosRegistrationClient{
 		mesosMasterType: mesosMasterType,
+		vmStitchingKey:  conf.StitchByIP,
+	}
+	if stitchingConf != nil && stitchingConf.VMKey != "" {
+		client.vmStitchingKey = stitchingConf.VMKey
+	}
+	if stitchingConf != nil {
+		client.standalone = stitchingConf.Standalone
 	}
 	return client
 }
 
 var (
 	vmType        proto.EntityDTO_EntityType = proto.EntityDTO_VIRTUAL_MACHINE
+	podType       proto.EntityDTO_EntityType = proto.EntityDTO_CONTAINER_POD
 	containerType proto.EntityDTO_EntityType = proto.EntityDTO_CONTAINER
 	appType       proto.EntityDTO_EntityType = proto.EntityDTO_APPLICATION
+	pmType        proto.EntityDTO_EntityType = proto.EntityDTO_PHYSICAL_MACHINE
+	dcType        proto.EntityDTO_EntityType = proto.EntityDTO_DATACENTER
 
 	vCpuType            proto.CommodityDTO_CommodityType = proto.CommodityDTO_VCPU
 	vMemType            proto.CommodityDTO_CommodityType = proto.CommodityDTO_VMEM
@@ -35,6 +48,12 @@ var (
 	appCommType         proto.CommodityDTO_CommodityType = proto.CommodityDTO_APPLICATION
 	clusterType         proto.CommodityDTO_CommodityType = proto.CommodityDTO_CLUSTER
 	networkType         proto.CommodityDTO_CommodityType = proto.CommodityDTO_NETWORK
+	accessType          proto.CommodityDTO_CommodityType = proto.CommodityDTO_VMPM_ACCESS
+	cpuType             proto.CommodityDTO_CommodityType = proto.CommodityDTO_CPU
+	memType             proto.CommodityDTO_CommodityType = proto.CommodityDTO_MEM
+	vStorageType        proto.CommodityDTO_CommodityType = proto.CommodityDTO_VSTORAGE
+	datacenterType      proto.CommodityDTO_CommodityType = proto.CommodityDTO_DATACENTER
+	segmentationType    proto.CommodityDTO_CommodityType = proto.CommodityDTO_SEGMENTATION
 
 	//Commodity key is optional, when key is set, it serves as a constraint between seller and buyer
 	//for example, the buyer can only go to a seller that sells the commodity with the required key
@@ -42,10 +61,19 @@ var (
 	vMemTemplateComm     *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &vMemType}
 	vCpuProvTemplateComm *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &vCpuProvisionedType}
 	vMemProvTemplateComm *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &vMemProvisionedType}
+	cpuTemplateComm      *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &cpuType}
+	memTemplateComm      *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &memType}
+	vStorageTemplateComm *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &vStorageType}
 
 	fakeKey                    string                   = "fake"
 	appTemplateCommWithKey     *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &appCommType, Key: &fakeKey}
 	clusterTemplateCommWithKey *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &clusterType, Key: &fakeKey}
+	// Roles and fault domains of the agents
+	accessTemplateCommWithKey *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &accessType, Key: &fakeKey}
+	// Placement constraints of the apps
+	segmentationTemplateCommWithKey *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &segmentationType, Key: &fakeKey}
+	// Datacenter of the physical machines in the standalone mode
+	datacenterTemplateCommWithKey *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &datacenterType, Key: &fakeKey}
 )
 
 func (registrationClient *MesosRegistrationClient) GetSupplyChainDefinition() []*proto.TemplateDTO {
@@ -55,7 +83,45 @@ func (registrationClient *MesosRegistrationClient) GetSupplyChainDefinition() []
 		Sells(vMemTemplateComm).
 		Sells(vCpuProvTemplateComm).
 		Sells(vMemProvTemplateComm).
-		Sells(clusterTemplateCommWithKey)
+		Sells(clusterTemplateCommWithKey).
+		Sells(accessTemplateCommWithKey).
+		Sells(segmentationTemplateCommWithKey)
+
+	// Physical Machine Node and Datacenter Node for the standalone mode
+	var pmSupplyChainNodeBuilder, dcSupplyChainNodeBuilder *supplychain.SupplyChainNodeBuilder
+	if registrationClient.standalone {
+		vmSupplyChainNodeBuilder = vmSupplyChainNodeBuilder.
+			Sells(vStorageTemplateComm).
+			Provider(pmType, proto.Provider_HOSTING).
+			Buys(cpuTemplateComm).
+			Buys(memTemplateComm)
+
+		pmSupplyChainNodeBuilder = supplychain.NewSupplyChainNodeBuilder(pmType).
+			Sells(cpuTemplateComm).
+			Sells(memTemplateComm).
+			Provider(dcType, proto.Provider_HOSTING).
+			Buys(datacenterTemplateCommWithKey)
+
+		dcSupplyChainNodeBuilder = supplychain.NewSupplyChainNodeBuilder(dcType).
+			Sells(datacenterTemplateCommWithKey)
+	}
+
+	// Pod Node for the Task Groups
+	podSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(podType).
+		Sells(vCpuTemplateComm).
+		Sells(vMemTemplateComm).
+		Sells(accessTemplateCommWithKey)
+
+	// Pod Node to VM Link
+	podSupplyChainNodeBuilder = podSupplyChainNodeBuilder.
+		Provider(vmType, proto.Provider_HOSTING).
+		Buys(vCpuTemplateComm).
+		Buys(vMemTemplateComm).
+		Buys(vCpuProvTemplateComm).
+		Buys(vMemProvTemplateComm).
+		Buys(clusterTemplateCommWithKey).
+		Buys(accessTemplateCommWithKey).
+		Buys(segmentationTemplateCommWithKey)
 
 	// Container Node
 	containerSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(containerType).
@@ -70,7 +136,16 @@ func (registrationClient *MesosRegistrationClient) GetSupplyChainDefinition() []
 		Buys(vMemTemplateComm).
 		Buys(vCpuProvTemplateComm).
 		Buys(vMemProvTemplateComm).
-		Buys(clusterTemplateCommWithKey)
+		Buys(clusterTemplateCommWithKey).
+		Buys(accessTemplateCommWithKey).
+		Buys(segmentationTemplateCommWithKey)
+
+	// Container Node to Pod Link for the tasks in a task group
+	containerSupplyChainNodeBuilder = containerSupplyChainNodeBuilder.
+		Provider(podType, proto.Provider_HOSTING).
+		Buys(vCpuTemplateComm).
+		Buys(vMemTemplateComm).
+		Buys(accessTemplateCommWithKey)
 
 	// Application Node
 	appSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(appType)
@@ -82,24 +157,10 @@ func (registrationClient *MesosRegistrationClient) GetSupplyChainDefinition() []
 		Buys(vMemTemplateComm).
 		Buys(appTemplateCommWithKey)
 
-	// External Link from Container (Pod) to VM
-	containerVmExtLinkBuilder := supplychain.NewExternalEntityLinkBuilder().
-		Link(containerType, vmType,
-			proto.Provider_HOSTING).
-		Commodity(vCpuType, false).
-		Commodity(vMemType, false).
-		Commodity(vCpuProvisionedType, false).
-		Commodity(vMemProvisionedType, false).
-		Commodity(clusterType, true).
-		ProbeEntityPropertyDef(supplychain.SUPPLY_CHAIN_CONSTANT_IP_ADDRESS,
-			"IP Address where the Container is running").
-		ExternalEntityPropertyDef(supplychain.VM_IP)
-
-	containerVmExternalLink, err := containerVmExtLinkBuilder.Build()
-	if err != nil {
-		glog.Errorf("[MesosRegistrationClient] error creating vm external link : %s", err)
+	// External Links to the VMs discovered by the other targets, the agent VMs are not stitched in the standalone mode
+	if !registrationClient.standalone {
+		registrationClient.connectToExternalVMs(containerSupplyChainNodeBuilder, podSupplyChainNodeBuilder)
 	}
-	containerSupplyChainNodeBuilder.ConnectsTo(containerVmExternalLink)
 
 	appNode, err := appSupplyChainNodeBuilder.Create()
 	if err != nil {
@@ -110,6 +171,11 @@ func (registrationClient *MesosRegistrationClient) GetSupplyChainDefinition() []
 		glog.Errorf("[MesosRegistrationClient] error creating container node :  %s", err)
 	}
 
+	podNode, err := podSupplyChainNodeBuilder.Create()
+	if err != nil {
+		glog.Errorf("[MesosRegistrationClient] error creating pod node :  %s", err)
+	}
+
 	vmNode, err := vmSupplyChainNodeBuilder.Create()
 	if err != nil {
 		glog.Errorf("[MesosRegistrationClient] error creating virtual machine node : %s", err)
@@ -119,8 +185,23 @@ func (registrationClient *MesosRegistrationClient) GetSupplyChainDefinition() []
 	supplyChainBuilder.
 		Top(appNode).
 		Entity(containerNode).
+		Entity(podNode).
 		Entity(vmNode)
 
+	if registrationClient.standalone {
+		pmNode, err := pmSupplyChainNodeBuilder.Create()
+		if err != nil {
+			glog.Errorf("[MesosRegistrationClient] error creating physical machine node : %s", err)
+		}
+		dcNode, err := dcSupplyChainNodeBuilder.Create()
+		if err != nil {
+			glog.Errorf("[MesosRegistrationClient] error creating datacenter node : %s", err)
+		}
+		supplyChainBuilder.
+			Entity(pmNode).
+			Entity(dcNode)
+	}
+
 	supplychain, err := supplyChainBuilder.Create()
 	if err != nil {
 		glog.Errorf("[MesosRegistrationClient] error creating supply chain  : %s", err)
@@ -130,6 +211,88 @@ func (registrationClient *MesosRegistrationClient) GetSupplyChainDefinition() []
 	return supplychain
 }
 
+// External links from the containers and pods to the VMs discovered by the other targets
+func (registrationClient *MesosRegistrationClient) connectToExternalVMs(containerSupplyChainNodeBuilder,
+	podSupplyChainNodeBuilder *supplychain.SupplyChainNodeBuilder) {
+	// External Link from Container (Pod) to VM
+	containerVmExtLinkBuilder := supplychain.NewExternalEntityLinkBuilder().
+		Link(containerType, vmType,
+			proto.Provider_HOSTING).
+		Commodity(vCpuType, false).
+		Commodity(vMemType, false).
+		Commodity(vCpuProvisionedType, false).
+		Commodity(vMemProvisionedType, false).
+		Commodity(clusterType, true).
+		Commodity(accessType, true).
+		Commodity(segmentationType, true)
+	containerVmExtLinkBuilder = registrationClient.vmLinkPropertyDefs(containerVmExtLinkBuilder, "Container")
+
+	containerVmExternalLink, err := containerVmExtLinkBuilder.Build()
+	if err != nil {
+		glog.Errorf("[MesosRegistrationClient] error creating vm external link : %s", err)
+	}
+	containerSupplyChainNodeBuilder.ConnectsTo(containerVmExternalLink)
+
+	// External Link from Pod to VM
+	podVmExtLinkBuilder := supplychain.NewExternalEntityLinkBuilder().
+		Link(podType, vmType,
+			proto.Provider_HOSTING).
+		Commodity(vCpuType, false).
+		Commodity(vMemType, false).
+		Commodity(vCpuProvisionedType, false).
+		Commodity(vMemProvisionedType, false).
+		Commodity(clusterType, true).
+		Commodity(accessType, true).
+		Commodity(segmentationType, true)
+	podVmExtLinkBuilder = registrationClient.vmLinkPropertyDefs(podVmExtLinkBuilder, "Pod")
+
+	podVmExternalLink, err := podVmExtLinkBuilder.Build()
+	if err != nil {
+		glog.Errorf("[MesosRegistrationClient] error creating pod vm external link : %s", err)
+	}
+	podSupplyChainNodeBuilder.ConnectsTo(podVmExternalLink)
+}
+
+// Property definitions for the external link to find the hosting VM using the VM stitching key.
+// The agent IP is matched against the IP addresses of the VMs in the server and the instance id or UUID
+// against the UUID of the VMs.
+func (registrationClient *MesosRegistrationClient) vmLinkPropertyDefs(linkBuilder *supplychain.ExternalEntityLinkBuilder,
+	entityName string) *supplychain.ExternalEntityLinkBuilder {
+	switch registrationClient.vmStitchingKey {
+	case conf.StitchByInstanceId, conf.StitchByUUID:
+		return linkBuilder.
+			ProbeEntityPropertyDef(supplychain.SUPPLY_CHAIN_CONSTANT_UUID,
+				"UUID of the VM where the "+entityName+" is running").
+			ExternalEntityPropertyDef(supplychain.VM_UUID)
+	}
+	return linkBuilder.
+		ProbeEntityPropertyDef(supplychain.SUPPLY_CHAIN_CONSTANT_IP_ADDRESS,
+			"IP Address where the "+entityName+" is running").
+		ExternalEntityPropertyDef(supplychain.VM_IP)
+}
+
+// Action policies for the containers and applications using the actions supported by the frameworks.
+// The policy has a single entry per entity type with the actions supported by any of the frameworks, and
+// the actions are not executable by the probe. The containers and applications of the tasks of the frameworks
+// that do not support the actions are discovered as non controllable entities.
+func (registrationClient *MesosRegistrationClient) GetActionPolicy() []*proto.ActionPolicyDTO {
+	policyBuilder := builder.NewActionPolicyBuilder()
+	for _, entityType := range []proto.EntityDTO_EntityType{containerType, appType} {
+		for _, actionType := range []proto.ActionItemDTO_ActionType{
+			proto.ActionItemDTO_MOVE, proto.ActionItemDTO_PROVISION,
+			proto.ActionItemDTO_SUSPEND, proto.ActionItemDTO_RIGHT_SIZE} {
+			policyBuilder.WithEntityActions(entityType, actionType, proto.ActionPolicyDTO_NOT_SUPPORTED)
+		}
+	}
+	for _, entityType := range []proto.EntityDTO_EntityType{containerType, appType} {
+		for _, actionType := range conf.GetEntityActions(entityType) {
+			glog.V(3).Infof("[MesosRegistrationClient] %s is supported for %s", actionType, entityType)
+			policyBuilder.WithEntityActions(entityType, actionType, proto.ActionPolicyDTO_NOT_EXECUTABLE)
+		}
+	}
+	return policyBuilder.Create()
+}
+
 func (registrationClient *MesosRegistrationClient) GetIdentifyingFields() string {
 	return string(conf.MasterIPPort)
 }
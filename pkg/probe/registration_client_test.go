@@ -17,8 +17,9 @@ func TestNewMesosRegistrationClient(t *testing.T) {
 	for _, y := range masters {
 		expectedClient := &MesosRegistrationClient{
 			mesosMasterType: y,
+			vmStitchingKey:  conf.StitchByIP,
 		}
-		client := NewRegistrationClient(y)
+		client := NewRegistrationClient(y, nil)
 		if !reflect.DeepEqual(expectedClient, client) {
 			t.Errorf("\nExpected %+v, \ngot      %+v", expectedClient, client)
 		}
@@ -27,7 +28,7 @@ func TestNewMesosRegistrationClient(t *testing.T) {
 
 func TestMesosSupplyChain(t *testing.T) {
 
-	client := NewRegistrationClient(conf.Apache)
+	client := NewRegistrationClient(conf.Apache, nil)
 
 	var supplychain []*proto.TemplateDTO
 	supplychain = client.GetSupplyChainDefinition()
@@ -41,17 +42,20 @@ func TestMesosSupplyChain(t *testing.T) {
 
 	assert.Contains(t, dtoMap, containerType, "Supply chain should contain Container")
 	assert.Contains(t, dtoMap, vmType, "Supply chain should contain VM")
+	assert.Contains(t, dtoMap, podType, "Supply chain should contain Pod")
 	assert.Contains(t, dtoMap, appType, "Supply chain should contain Application")
 	assert.NotContains(t, dtoMap, proto.EntityDTO_APPLICATION_SERVER, "Should not contain ApplicationServer")
 
 	containerDto := dtoMap[containerType]
 	vmDto := dtoMap[vmType]
 	appDto := dtoMap[appType]
+	podDto := dtoMap[podType]
 
 	// External link
 	assert.Equal(t, 1, len(containerDto.GetExternalLink()))
 	assert.Equal(t, 0, len(vmDto.GetExternalLink()))
 	assert.Equal(t, 0, len(appDto.GetExternalLink()))
+	assert.Equal(t, 1, len(podDto.GetExternalLink()))
 
 	var links []*proto.TemplateDTO_ExternalEntityLinkProp
 	links = containerDto.GetExternalLink()
@@ -64,24 +68,64 @@ func TestMesosSupplyChain(t *testing.T) {
 	expectedSoldComms := []proto.CommodityDTO_CommodityType{vCpuType, vMemType, appCommType}
 	testCommsSold(t, containerDto, expectedSoldComms)
 
-	expectedSoldComms = []proto.CommodityDTO_CommodityType{vCpuType, vMemType, vCpuProvisionedType, vMemProvisionedType, clusterType}
+	expectedSoldComms = []proto.CommodityDTO_CommodityType{vCpuType, vMemType, vCpuProvisionedType, vMemProvisionedType, clusterType, accessType, segmentationType}
 	testCommsSold(t, vmDto, expectedSoldComms)
 
 	expectedSoldComms = []proto.CommodityDTO_CommodityType{}
 	testCommsSold(t, appDto, expectedSoldComms)
 
+	expectedSoldComms = []proto.CommodityDTO_CommodityType{vCpuType, vMemType, accessType}
+	testCommsSold(t, podDto, expectedSoldComms)
+
 	// Commodities Bought
 	var expectedBoughtComms map[proto.EntityDTO_EntityType][]proto.CommodityDTO_CommodityType
 
 	expectedBoughtComms = make(map[proto.EntityDTO_EntityType][]proto.CommodityDTO_CommodityType)
-	expectedBoughtComms[vmType] = []proto.CommodityDTO_CommodityType{vCpuType, vMemType, vCpuProvisionedType, vMemProvisionedType, clusterType}
+	expectedBoughtComms[vmType] = []proto.CommodityDTO_CommodityType{vCpuType, vMemType, vCpuProvisionedType, vMemProvisionedType, clusterType, accessType, segmentationType}
+	expectedBoughtComms[podType] = []proto.CommodityDTO_CommodityType{vCpuType, vMemType, accessType}
 	testCommsBought(t, containerDto, expectedBoughtComms) // Container
 
+	expectedBoughtComms = make(map[proto.EntityDTO_EntityType][]proto.CommodityDTO_CommodityType)
+	expectedBoughtComms[vmType] = []proto.CommodityDTO_CommodityType{vCpuType, vMemType, vCpuProvisionedType, vMemProvisionedType, clusterType, accessType, segmentationType}
+	testCommsBought(t, podDto, expectedBoughtComms) // Pod
+
 	expectedBoughtComms = make(map[proto.EntityDTO_EntityType][]proto.CommodityDTO_CommodityType)
 	expectedBoughtComms[containerType] = []proto.CommodityDTO_CommodityType{vCpuType, vMemType, appCommType}
 	testCommsBought(t, appDto, expectedBoughtComms) // Application
 }
 
+func TestMesosStandaloneSupplyChain(t *testing.T) {
+	client := NewRegistrationClient(conf.Apache, &conf.StitchingConf{Standalone: true})
+
+	dtoMap := make(map[proto.EntityDTO_EntityType]*proto.TemplateDTO)
+	for _, templateDto := range client.GetSupplyChainDefinition() {
+		dtoMap[*templateDto.TemplateClass] = templateDto
+	}
+
+	assert.Contains(t, dtoMap, pmType, "Supply chain should contain PhysicalMachine")
+	assert.Contains(t, dtoMap, dcType, "Supply chain should contain Datacenter")
+
+	// No external links since the agent VMs are not stitched
+	for entityType, templateDto := range dtoMap {
+		assert.Equal(t, 0, len(templateDto.GetExternalLink()), "Unexpected external link for "+entityType.String())
+	}
+
+	vmDto := dtoMap[vmType]
+	expectedSoldComms := []proto.CommodityDTO_CommodityType{vCpuType, vMemType, vCpuProvisionedType, vMemProvisionedType,
+		clusterType, accessType, vStorageType}
+	testCommsSold(t, vmDto, expectedSoldComms)
+	testCommsSold(t, dtoMap[pmType], []proto.CommodityDTO_CommodityType{cpuType, memType})
+	testCommsSold(t, dtoMap[dcType], []proto.CommodityDTO_CommodityType{datacenterType})
+
+	expectedBoughtComms := make(map[proto.EntityDTO_EntityType][]proto.CommodityDTO_CommodityType)
+	expectedBoughtComms[pmType] = []proto.CommodityDTO_CommodityType{cpuType, memType}
+	testCommsBought(t, vmDto, expectedBoughtComms) // VM
+
+	expectedBoughtComms = make(map[proto.EntityDTO_EntityType][]proto.CommodityDTO_CommodityType)
+	expectedBoughtComms[dcType] = []proto.CommodityDTO_CommodityType{datacenterType}
+	testCommsBought(t, dtoMap[pmType], expectedBoughtComms) // PM
+}
+
 func TestIdentifyingField(t *testing.T) {
 	var masters []conf.MesosMasterType
 	masters = append(masters, conf.Apache)
@@ -89,13 +133,13 @@ func TestIdentifyingField(t *testing.T) {
 
 	idField := conf.MasterIPPort
 	for _, masterType := range masters {
-		client := NewRegistrationClient(masterType)
+		client := NewRegistrationClient(masterType, nil)
 		assert.Equal(t, string(idField), client.GetIdentifyingFields())
 	}
 }
 
 func TestIdentifyingFieldInMesosAcctMap(t *testing.T) {
-	client := NewRegistrationClient(conf.Apache)
+	client := NewRegistrationClient(conf.Apache, nil)
 
 	idField := client.GetIdentifyingFields()
 	var acctDefEntryMap map[string]*proto.AccountDefEntry
@@ -111,7 +155,7 @@ func TestIdentifyingFieldInMesosAcctMap(t *testing.T) {
 }
 
 func TestApacheMesosAccountMap(t *testing.T) {
-	client := NewRegistrationClient(conf.Apache)
+	client := NewRegistrationClient(conf.Apache, nil)
 
 	expectedFields := [...]string{client.GetIdentifyingFields(), string(conf.MasterIPPort),
 		string(conf.MasterUsername), string(conf.MasterPassword)}
@@ -131,7 +175,7 @@ func TestApacheMesosAccountMap(t *testing.T) {
 }
 
 func TestDCOSMesosAccountMap(t *testing.T) {
-	client := NewRegistrationClient(conf.DCOS)
+	client := NewRegistrationClient(conf.DCOS, nil)
 
 	expectedFields := [...]string{client.GetIdentifyingFields(), string(conf.MasterIPPort),
 		string(conf.MasterUsername), string(conf.MasterPassword)}
@@ -189,3 +233,17 @@ func testCommsBoughtPerProvider(t *testing.T, templateComms []*proto.TemplateCom
 
 	}
 }
+
+func TestMesosActionPolicy(t *testing.T) {
+	client := NewRegistrationClient(conf.Apache, nil)
+
+	capabilities := make(map[proto.EntityDTO_EntityType]map[proto.ActionItemDTO_ActionType]proto.ActionPolicyDTO_ActionCapability)
+	for _, policy := range client.GetActionPolicy() {
+		capabilities[policy.GetEntityType()] = make(map[proto.ActionItemDTO_ActionType]proto.ActionPolicyDTO_ActionCapability)
+		for _, element := range policy.GetPolicyElement() {
+			capabilities[policy.GetEntityType()][element.GetActionType()] = element.GetActionCapability()
+		}
+	}
+	assert.Equal(t, proto.ActionPolicyDTO_NOT_EXECUTABLE, capabilities[containerType][proto.ActionItemDTO_MOVE])
+	assert.Equal(t, proto.ActionPolicyDTO_NOT_SUPPORTED, capabilities[appType][proto.ActionItemDTO_MOVE])
+}
@@ -0,0 +1,118 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/glog"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	queryPath     string = "/api/v1/query"
+	statusSuccess string = "success"
+	resultVector  string = "vector"
+)
+
+const PrometheusClientClass = "[PrometheusClient] "
+
+// Client to execute PromQL queries using the Prometheus Rest API
+type PrometheusClient struct {
+	serverUrl  string
+	httpClient *http.Client
+}
+
+// Create a new client for the Prometheus server at the given url
+func NewPrometheusClient(serverUrl string, timeout time.Duration) *PrometheusClient {
+	return &PrometheusClient{
+		serverUrl:  strings.TrimSuffix(serverUrl, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Sample in the instant vector returned by a query
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Response for the query rest api
+type queryResponse struct {
+	Status    string    `json:"status"`
+	Data      queryData `json:"data"`
+	ErrorType string    `json:"errorType"`
+	Error     string    `json:"error"`
+}
+
+type queryData struct {
+	ResultType string        `json:"resultType"`
+	Result     []queryResult `json:"result"`
+}
+
+type queryResult struct {
+	Metric map[string]string `json:"metric"`
+	// [ <unix time>, "<sample value>" ]
+	Value []interface{} `json:"value"`
+}
+
+// Execute the instant query and return the samples in the resulting vector.
+// The query is cancelled when the given context is done.
+func (client *PrometheusClient) Query(ctx context.Context, query string) ([]*Sample, error) {
+	fullUrl := client.serverUrl + queryPath + "?query=" + url.QueryEscape(query)
+	glog.V(4).Infof(PrometheusClientClass+"Query %s", fullUrl)
+	request, err := http.NewRequest("GET", fullUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf(PrometheusClientClass+"Error creating request for query %s : %s", query, err)
+	}
+	resp, err := client.httpClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf(PrometheusClientClass+"Error executing query %s : %s", query, err)
+	}
+	defer resp.Body.Close()
+
+	byteContent, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf(PrometheusClientClass+"Error reading response for query %s : %s", query, err)
+	}
+
+	var queryResp queryResponse
+	if err := json.Unmarshal(byteContent, &queryResp); err != nil {
+		return nil, fmt.Errorf(PrometheusClientClass+"Error in json unmarshal for query %s : %s", query, err)
+	}
+	if queryResp.Status != statusSuccess {
+		return nil, fmt.Errorf(PrometheusClientClass+"Query %s failed : %s %s", query, queryResp.ErrorType, queryResp.Error)
+	}
+	if queryResp.Data.ResultType != resultVector {
+		return nil, fmt.Errorf(PrometheusClientClass+"Unsupported result type %s for query %s", queryResp.Data.ResultType, query)
+	}
+
+	var samples []*Sample
+	for _, result := range queryResp.Data.Result {
+		value, err := parseSampleValue(result.Value)
+		if err != nil {
+			glog.Warningf(PrometheusClientClass+"Invalid sample %v for query %s : %s", result.Value, query, err)
+			continue
+		}
+		samples = append(samples, &Sample{
+			Labels: result.Metric,
+			Value:  value,
+		})
+	}
+	return samples, nil
+}
+
+func parseSampleValue(value []interface{}) (float64, error) {
+	if len(value) != 2 {
+		return 0, fmt.Errorf("expected timestamp and value")
+	}
+	strValue, ok := value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("sample value is not a string")
+	}
+	return strconv.ParseFloat(strValue, 64)
+}
@@ -0,0 +1,82 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestServer(body string, status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != queryPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+}
+
+func TestQueryParsesVector(t *testing.T) {
+	server := newTestServer(`{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"task_id":"t1"},"value":[1500000000.1,"2.5"]},
+		{"metric":{"task_id":"t2"},"value":[1500000000.1,"NaN-value"]},
+		{"metric":{"task_id":"t3"},"value":[1500000000.1,"4"]}]}}`, http.StatusOK)
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL+"/", time.Second)
+	samples, err := client.Query(context.Background(), `sum(rate(cpu[1m])) by (task_id)`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// the sample with an invalid value is skipped
+	if len(samples) != 2 {
+		t.Fatalf("Expected 2 samples, got %d", len(samples))
+	}
+	if samples[0].Labels["task_id"] != "t1" || samples[0].Value != 2.5 {
+		t.Errorf("Unexpected sample %+v", samples[0])
+	}
+	if samples[1].Labels["task_id"] != "t3" || samples[1].Value != 4 {
+		t.Errorf("Unexpected sample %+v", samples[1])
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"failed query", `{"status":"error","errorType":"bad_data","error":"parse error"}`, http.StatusBadRequest},
+		{"unsupported result type", `{"status":"success","data":{"resultType":"matrix","result":[]}}`, http.StatusOK},
+		{"invalid json", `not json`, http.StatusOK},
+	}
+	for _, test := range tests {
+		server := newTestServer(test.body, test.status)
+		client := NewPrometheusClient(server.URL, time.Second)
+		if _, err := client.Query(context.Background(), "up"); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+		server.Close()
+	}
+
+	// server not reachable
+	client := NewPrometheusClient("http://127.0.0.1:1", time.Second)
+	if _, err := client.Query(context.Background(), "up"); err == nil {
+		t.Errorf("Expected error for the unreachable server")
+	}
+}
+
+func TestParseSampleValue(t *testing.T) {
+	if value, err := parseSampleValue([]interface{}{1.0, "3.5"}); err != nil || value != 3.5 {
+		t.Errorf("Unexpected value %f %v", value, err)
+	}
+	for _, invalid := range [][]interface{}{{1.0}, {1.0, 3.5}, {1.0, "x"}} {
+		if _, err := parseSampleValue(invalid); err == nil {
+			t.Errorf("Expected error for %v", invalid)
+		}
+	}
+}